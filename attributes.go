@@ -0,0 +1,174 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Patterns of files, with the syntax of Git, used by default to mark them as
+// generated or binary.
+var (
+	defaultGenerated = []string{"*_gen.go"}
+	defaultBinary    = []string{"testdata/**"}
+)
+
+// textFiles are the patterns of the text files of a project, for the VCSs
+// which do not detect them.
+var textFiles = []string{"*.go", "*.md", "*.txt", "*.mod", "*.sum", "*.work",
+	"*.yaml", "*.json"}
+
+// fileAttributes are the attributes of the files of a project. The line
+// endings are always LF.
+type fileAttributes struct {
+	generated []string // patterns of generated files
+	binary    []string // patterns of binary files
+}
+
+// Optional features of a VCS, about the attributes of files.
+type (
+	// attributesWriter returns the file with the attributes of files, and
+	// the policy of line endings, relative to the repository root.
+	attributesWriter interface {
+		Attributes(attr fileAttributes) (name string, data []byte)
+	}
+
+	// attributesSetter sets the attributes, or enables their use, in the
+	// repository.
+	attributesSetter interface {
+		SetAttributes(dir string, attr fileAttributes) error
+	}
+)
+
+// patternList returns the patterns of the list, or the ones by default if it
+// is empty. The value "none" is used to not set any.
+func patternList(list, def []string) []string {
+	if len(list) == 0 {
+		return def
+	}
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v == "none" {
+			return nil
+		} else if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// attributes returns the attributes of the files of the project.
+func (c *Conf) attributes() fileAttributes {
+	return fileAttributes{
+		generated: patternList(c.Generated, defaultGenerated),
+		binary:    patternList(c.Binary, defaultBinary),
+	}
+}
+
+// renderAttributes renders the file with the attributes of files for the VCS.
+// Returns an empty name if the VCS does not use it.
+func (p *project) renderAttributes() (name string, data []byte) {
+	if v, ok := vcsBackends[p.cfg.VCS].(attributesWriter); ok {
+		return v.Attributes(p.cfg.attributes())
+	}
+	return "", nil
+}
+
+// gitAttributes returns the file ".gitattributes".
+func gitAttributes(attr fileAttributes) (string, []byte) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`# Line endings: LF in the repository and the working tree
+* text=auto eol=lf
+
+*.go diff=golang
+`)
+	if len(attr.generated) != 0 {
+		buf.WriteString("\n# Generated files\n")
+		for _, v := range attr.generated {
+			fmt.Fprintf(&buf, "%s linguist-generated\n", v)
+		}
+	}
+	if len(attr.binary) != 0 {
+		buf.WriteString("\n# Binary files\n")
+		for _, v := range attr.binary {
+			fmt.Fprintf(&buf, "%s binary\n", v)
+		}
+	}
+	return ".gitattributes", buf.Bytes()
+}
+
+func (gitVCS) Attributes(attr fileAttributes) (string, []byte) { return gitAttributes(attr) }
+func (jjVCS) Attributes(attr fileAttributes) (string, []byte)  { return gitAttributes(attr) }
+
+// Attributes returns the file ".hgeol", used by the extension "eol". Mercurial
+// does not mark the generated files.
+func (hgVCS) Attributes(attr fileAttributes) (string, []byte) {
+	var buf bytes.Buffer
+
+	// The first pattern which matches is used.
+	buf.WriteString("[patterns]\n")
+	for _, v := range attr.binary {
+		// Without slashes, the pattern matches in any directory.
+		switch {
+		case strings.Contains(strings.TrimSuffix(v, "/"), "/"):
+			v = strings.TrimPrefix(v, "/")
+		case v[0] == '*':
+			v = "*" + v
+		default:
+			v = "**/" + v
+		}
+		fmt.Fprintf(&buf, "%s = BIN\n", v)
+	}
+	buf.WriteString("** = LF\n")
+
+	return ".hgeol", buf.Bytes()
+}
+
+// SetAttributes enables the extension "eol" in the repository.
+func (hgVCS) SetAttributes(dir string, attr fileAttributes) error {
+	return appendFile(filepath.Join(dir, ".hg", "hgrc"), "[extensions]\neol =\n")
+}
+
+// Attributes returns the setting "binary-glob". Fossil does not convert the
+// line endings, but it warns about the files with CRLF at committing.
+func (fossilVCS) Attributes(attr fileAttributes) (string, []byte) {
+	if len(attr.binary) == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	for _, v := range attr.binary {
+		buf.WriteString(globPattern(v) + "\n")
+	}
+	return ".fossil-settings/binary-glob", buf.Bytes()
+}
+
+// SetAttributes sets the property "svn:auto-props" in the root directory, so
+// the files added later get the line endings LF, or the binary type.
+// Subversion matches the names of files, not their paths.
+func (svnVCS) SetAttributes(dir string, attr fileAttributes) error {
+	var buf bytes.Buffer
+
+	for _, v := range attr.binary {
+		if !strings.Contains(v, "/") {
+			fmt.Fprintf(&buf, "%s = svn:mime-type=application/octet-stream\n", v)
+		}
+	}
+	for _, v := range textFiles {
+		fmt.Fprintf(&buf, "%s = svn:eol-style=LF\n", v)
+	}
+	return svnPropset(dir, "svn:auto-props", buf.Bytes())
+}
+
+// globPattern converts a pattern of Git to a glob where "*" matches "/" too.
+func globPattern(pattern string) string {
+	return strings.Replace(strings.TrimPrefix(pattern, "/"), "**", "*", -1)
+}
@@ -0,0 +1,166 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatternList(t *testing.T) {
+	def := []string{"*_gen.go"}
+
+	tests := []struct {
+		list []string
+		want []string
+	}{
+		{nil, def},
+		{[]string{"*.pb.go", " zz_*.go "}, []string{"*.pb.go", "zz_*.go"}},
+		{[]string{"*.pb.go", ""}, []string{"*.pb.go"}},
+		{[]string{"none"}, nil},
+		{[]string{"*.pb.go", "none"}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := patternList(tt.list, def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("patternList(%q): got %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestGitAttributes(t *testing.T) {
+	name, data := gitVCS{}.Attributes((&Conf{}).attributes())
+	if name != ".gitattributes" {
+		t.Errorf("name: got %q", name)
+	}
+	want := `# Line endings: LF in the repository and the working tree
+* text=auto eol=lf
+
+*.go diff=golang
+
+# Generated files
+*_gen.go linguist-generated
+
+# Binary files
+testdata/** binary
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Jujutsu uses the same file.
+	if _, jjData := (jjVCS{}).Attributes((&Conf{}).attributes()); string(jjData) != want {
+		t.Errorf("jj: got:\n%s", jjData)
+	}
+
+	// Without patterns.
+	_, data = gitVCS{}.Attributes((&Conf{Generated: []string{"none"}, Binary: []string{"none"}}).attributes())
+	if strings.Contains(string(data), "linguist-generated") || strings.Contains(string(data), " binary") {
+		t.Errorf("got patterns:\n%s", data)
+	}
+}
+
+func TestHgAttributes(t *testing.T) {
+	attr := fileAttributes{binary: []string{"testdata/**", "/assets/", "*.png", "logo.ico"}}
+
+	name, data := hgVCS{}.Attributes(attr)
+	if name != ".hgeol" {
+		t.Errorf("name: got %q", name)
+	}
+	want := `[patterns]
+testdata/** = BIN
+assets/ = BIN
+**.png = BIN
+**/logo.ico = BIN
+** = LF
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestFossilAttributes(t *testing.T) {
+	attr := fileAttributes{binary: []string{"testdata/**", "/assets/*.png"}}
+
+	name, data := fossilVCS{}.Attributes(attr)
+	if name != ".fossil-settings/binary-glob" {
+		t.Errorf("name: got %q", name)
+	}
+	if want := "testdata/*\nassets/*.png\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	// Without binary files, the setting is not written.
+	if name, _ = (fossilVCS{}).Attributes(fileAttributes{}); name != "" {
+		t.Errorf("without binary files: got %q", name)
+	}
+}
+
+func TestSetAttributes(t *testing.T) {
+	setDataDir(t)
+	bin := fakeVCS(t, "hg", "fossil", "svnadmin", "svn")
+
+	// Mercurial: the file ".hgeol" is committed, and the extension "eol" is
+	// enabled in the repository.
+	p := newTestProject(t, &Conf{VCS: "hg"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(p.cfg.Program, ".hgeol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "** = LF\n") {
+		t.Errorf(".hgeol: got:\n%s", data)
+	}
+	if data, err = os.ReadFile(filepath.Join(p.cfg.Program, ".hg", "hgrc")); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[extensions]\neol =\n" {
+		t.Errorf("hgrc: got %q", data)
+	}
+	fakeLog(t, bin)
+
+	// Subversion: the property "svn:auto-props" has the binary files, and
+	// the line endings of the text files. The binary patterns with a slash
+	// are not used, since Subversion matches the names of files.
+	p = newTestProject(t, &Conf{Project: "Other", VCS: "svn", Binary: []string{"*.png", "testdata/**"}})
+	if err = p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(filepath.Join(bin, "svn:auto-props")); err != nil {
+		t.Fatal(err)
+	}
+	props := string(data)
+
+	if !strings.HasPrefix(props, "*.png = svn:mime-type=application/octet-stream\n") {
+		t.Errorf("binary files: got:\n%s", props)
+	}
+	if strings.Contains(props, "testdata") {
+		t.Errorf("got pattern with slash:\n%s", props)
+	}
+	for _, v := range textFiles {
+		if !strings.Contains(props, v+" = svn:eol-style=LF\n") {
+			t.Errorf("text file %q not found:\n%s", v, props)
+		}
+	}
+
+	// Fossil: the setting "binary-glob" is committed.
+	p = newTestProject(t, &Conf{Project: "Third", VCS: "fossil"})
+	if err = p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(filepath.Join(p.cfg.Program, ".fossil-settings", "binary-glob")); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "testdata/*\n" {
+		t.Errorf("binary-glob: got %q", data)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefixes of the names of license files, in lower case.
+var licenseFilePrefixes = []string{"license", "licence", "copying"}
+
+// AuditStatus is the result of the audit of a dependency.
+type AuditStatus string
+
+// Statuses of the audit
+const (
+	AuditOK        AuditStatus = "ok"       // compatible license
+	AuditConflict  AuditStatus = "conflict" // license incompatible with the project one
+	AuditUnknown   AuditStatus = "unknown"  // license not recognized
+	AuditNoLicense AuditStatus = "none"     // no license file
+	AuditMissing   AuditStatus = "missing"  // not found in the module cache
+)
+
+// LicenseAudit is the license of a dependency.
+type LicenseAudit struct {
+	Module  Module
+	License string  // name of the license, like "MIT"; empty if it is unknown
+	Score   float64 // similarity with the text of the license
+	Status  AuditStatus
+}
+
+// AuditLicenses classifies the licenses of the modules required by the project
+// in "dir", got from the local module cache, and checks whether they
+// are compatible with the license of the project.
+// The license files are compared with the texts of the data directory.
+func AuditLicenses(dir string) ([]LicenseAudit, error) {
+	cfg, err := projectConf(dir)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewProject(cfg)
+	if err != nil {
+		return nil, err
+	}
+	corpus, err := loadLicenseCorpus(p.dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err := requiredModules(dir, cfg.Modules)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := make([]LicenseAudit, 0, len(deps))
+	for _, mod := range deps {
+		res := LicenseAudit{Module: mod}
+
+		files, err := licenseFiles(moduleDir(mod))
+		switch {
+		case os.IsNotExist(err):
+			res.Status = AuditMissing
+		case err != nil:
+			return nil, err
+		case len(files) == 0:
+			res.Status = AuditNoLicense
+		default:
+			for _, v := range files {
+				data, err := os.ReadFile(v)
+				if err != nil {
+					return nil, err
+				}
+				if name, score := corpus.classify(string(data)); score > res.Score {
+					res.License, res.Score = name, score
+				}
+			}
+
+			switch {
+			case res.License == "":
+				res.Status = AuditUnknown
+			case depLicenseConflict(cfg.License, res.License):
+				res.Status = AuditConflict
+			default:
+				res.Status = AuditOK
+			}
+		}
+		audit = append(audit, res)
+	}
+	return audit, nil
+}
+
+// licenseFiles returns the license files at the root of the directory.
+func licenseFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+	for _, v := range entries {
+		if v.IsDir() {
+			continue
+		}
+		name := strings.ToLower(v.Name())
+		for _, prefix := range licenseFilePrefixes {
+			if strings.HasPrefix(name, prefix) {
+				files = append(files, filepath.Join(dir, v.Name()))
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
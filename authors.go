@@ -0,0 +1,293 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files with the authors, for copyright purposes, and the contributors.
+const (
+	_AUTHORS      = "AUTHORS.txt.md"
+	_CONTRIBUTORS = "CONTRIBUTORS.txt.md"
+
+	_LIST_SEPARATOR = "* * *" // line before the list of names
+)
+
+// Person is an author or contributor of the project.
+type Person struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email,omitempty"`
+	Org   string `yaml:"org,omitempty"` // organization which holds the copyright
+}
+
+// key returns the key to know if two persons are the same one.
+func (p Person) key() string {
+	if p.Email != "" {
+		return strings.ToLower(p.Email)
+	}
+	return strings.ToLower(p.Name)
+}
+
+// sortPersons sorts the list by name, removing the duplicated persons.
+// The data of a duplicated person is merged into the first one.
+func sortPersons(list []Person) []Person {
+	out := make([]Person, 0, len(list))
+	index := make(map[string]int)
+
+	for _, p := range list {
+		if p.Name == "" && p.Email == "" {
+			continue
+		}
+		if i, ok := index[p.key()]; ok {
+			if out[i].Name == "" {
+				out[i].Name = p.Name
+			}
+			if out[i].Org == "" {
+				out[i].Org = p.Org
+			}
+			continue
+		}
+		index[p.key()] = len(out)
+		out = append(out, p)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+// sortLines sorts the lines, removing the duplicated ones.
+func sortLines(lines []string) []string {
+	sort.Slice(lines, func(i, j int) bool {
+		return strings.ToLower(lines[i]) < strings.ToLower(lines[j])
+	})
+
+	out := lines[:0]
+	for i, v := range lines {
+		if i == 0 || !strings.EqualFold(v, lines[i-1]) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// AuthorLines returns the lines of the file of authors, the copyright holders:
+// the organization of every author, or else its name and email.
+// Without authors, it is used the organization or the author of the project.
+func (c *Conf) AuthorLines() []string {
+	list := c.Authors
+	if len(list) == 0 {
+		list = []Person{{Name: c.Author, Email: c.Email, Org: c.Org}}
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, p := range sortPersons(list) {
+		if p.Org != "" {
+			lines = append(lines, p.Org)
+		} else {
+			lines = append(lines, c.address("authors", p.Name, p.Email))
+		}
+	}
+	return sortLines(lines)
+}
+
+// ContributorLines returns the lines of the file of contributors, with the
+// name and email of every one.
+// Without contributors, it is used the author of the project.
+func (c *Conf) ContributorLines() []string {
+	list := c.Contributors
+	if len(list) == 0 {
+		list = []Person{{Name: c.Author, Email: c.Email}}
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, p := range sortPersons(list) {
+		lines = append(lines, c.address("contributors", p.Name, p.Email))
+	}
+	return sortLines(lines)
+}
+
+// readPersons returns the persons listed in the file of authors or
+// contributors, after the separator line.
+func readPersons(name string) ([]Person, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list := make([]Person, 0)
+	inList := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == _LIST_SEPARATOR:
+			inList = true
+		case inList && line != "":
+			p := Person{Name: line}
+			if i := strings.IndexByte(line, '<'); i != -1 && strings.HasSuffix(line, ">") {
+				p.Name = strings.TrimSpace(line[:i])
+				p.Email = unobfuscateEmail(line[i:])
+			}
+			list = append(list, p)
+		}
+	}
+	return list, scanner.Err()
+}
+
+// gitPersons returns the authors of the commits, and their co-authors, in the
+// Git repository of the directory "dir". The names are mapped by ".mailmap".
+func gitPersons(dir string) ([]Person, error) {
+	cmd := exec.Command("git", "log",
+		"--format=%aN%x00%aE%n%(trailers:key=Co-authored-by,valueonly)")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) != 0 {
+			return nil, fmt.Errorf("git log: %s", bytes.TrimSpace(e.Stderr))
+		}
+		return nil, fmt.Errorf("git log: %s", err)
+	}
+
+	list := make([]Person, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if name, email, found := strings.Cut(line, "\x00"); found {
+			list = append(list, Person{Name: name, Email: email})
+		} else if name, email := splitAddress(line); name != "" || email != "" {
+			list = append(list, Person{Name: name, Email: email})
+		}
+	}
+	return list, scanner.Err()
+}
+
+// SyncAuthors rebuilds the list of the files of authors and contributors of
+// the project created in "dir", adding the authors of the commits in its Git
+// history to the persons already listed in the files and in the
+// configuration. The text before of the list is kept; when the file has not
+// the list, it is merged like in an upgrade.
+//
+// All of them are contributors. They are also authors, copyright holders,
+// when the project is not developed for an organization.
+func SyncAuthors(dir string) ([]UpgradeChange, error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	history, err := gitPersons(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	contributors, err := readPersons(filepath.Join(dir, _CONTRIBUTORS))
+	if err != nil {
+		return nil, err
+	}
+	m.Conf.Contributors = sortPersons(append(append(m.Conf.Contributors, contributors...), history...))
+
+	if m.Conf.Org == "" {
+		authors, err := readPersons(filepath.Join(dir, _AUTHORS))
+		if err != nil {
+			return nil, err
+		}
+		m.Conf.Authors = sortPersons(append(append(m.Conf.Authors, authors...), history...))
+	}
+
+	p, err := NewProject(m.Conf)
+	if err != nil {
+		return nil, err
+	}
+	p.parseLicense()
+	p.parseProject()
+
+	changes := make([]UpgradeChange, 0, 2)
+	for _, v := range []struct{ file, tmplName string }{
+		{_AUTHORS, "Authors"},
+		{_CONTRIBUTORS, "Contributors"},
+	} {
+		if _, ok := m.Files[v.file]; !ok {
+			continue // not created, like the authors for CC0
+		}
+
+		data, err := p.renderVar(v.tmplName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", v.file, err)
+		}
+
+		dst := filepath.Join(dir, v.file)
+		current, err := os.ReadFile(dst)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		action := UpgradeUnchanged
+		if out, ok := replacePersonList(current, data); ok {
+			if !bytes.Equal(current, out) {
+				if err = writeFile(dst, out); err != nil {
+					return nil, err
+				}
+				action = UpgradeUpdated
+			}
+		} else if action, err = upgradeFile(dst, m.Files[v.file], data); err != nil {
+			// The file has not the list, or it has been removed.
+			return nil, err
+		}
+
+		m.Files[v.file] = string(data)
+		changes = append(changes, UpgradeChange{v.file, action})
+	}
+
+	if err = m.write(dir); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// replacePersonList returns the file "current" with the list of persons, from
+// the separator line, replaced by the one of "rendered". Returns false if any
+// of them has not the separator.
+func replacePersonList(current, rendered []byte) ([]byte, bool) {
+	index := func(data []byte) int {
+		pos := 0
+		for _, line := range splitLines(data) {
+			if strings.TrimSpace(line) == _LIST_SEPARATOR {
+				return pos
+			}
+			pos += len(line)
+		}
+		return -1
+	}
+
+	i, j := index(current), index(rendered)
+	if i == -1 || j == -1 {
+		return nil, false
+	}
+	out := make([]byte, 0, i+len(rendered)-j)
+	out = append(out, current[:i]...)
+	return append(out, rendered[j:]...), true
+}
@@ -0,0 +1,85 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncAuthors(t *testing.T) {
+	setDataDir(t)
+	setGitIdentity(t)
+
+	p := newTestProject(t, &Conf{VCS: "git", Commit: true, Email: "jane@example.com"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "Fix", "--author", "Bob Smith <bob@example.com>")
+
+	// Edits by hand, out of the list and in it.
+	authors := filepath.Join(dir, _AUTHORS)
+	data, err := os.ReadFile(authors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "Please keep the list sorted.\n",
+		"Please keep the list sorted.\nThe list is updated from the Git history.\n", 1)
+	writeTestFile(t, authors, edited)
+
+	contributors := filepath.Join(dir, _CONTRIBUTORS)
+	data, err = os.ReadFile(contributors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, contributors, string(data)+"Ann Lee <ann AT example.com>\n")
+
+	changes, err := SyncAuthors(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range changes {
+		if v.Action != UpgradeUpdated {
+			t.Errorf("%s: got action %q", v.File, v.Action)
+		}
+	}
+
+	data, err = os.ReadFile(authors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "The list is updated from the Git history.\n* * *\n") {
+		t.Errorf("text edited by hand not kept:\n%s", data)
+	}
+	wantList := "* * *\n\nBob Smith <bob AT example.com>\nJane Doe <jane AT example.com>\n"
+	if !strings.HasSuffix(strings.TrimRight(string(data), "\n")+"\n", wantList) {
+		t.Errorf("authors: got:\n%s\nwant the list:\n%s", data, wantList)
+	}
+
+	data, err = os.ReadFile(contributors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"Ann Lee <ann AT example.com>", "Bob Smith <bob AT example.com>"} {
+		if strings.Count(string(data), v) != 1 {
+			t.Errorf("contributor %q not listed once:\n%s", v, data)
+		}
+	}
+
+	// Nothing changes the next time.
+	if changes, err = SyncAuthors(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range changes {
+		if v.Action != UpgradeUnchanged {
+			t.Errorf("%s: got action %q", v.File, v.Action)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// _CHANGELOG is the changelog, in the format of "Keep a Changelog".
+const _CHANGELOG = "CHANGELOG.md"
+
+// _UNRELEASED is the section of the changelog with the changes not released.
+const _UNRELEASED = "Unreleased"
+
+var (
+	// https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string
+	reSemver = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+	// Heading of a section of the changelog, like "## [1.0.0] - 2006-01-02".
+	reChangelogSection = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?`)
+)
+
+// Version is a semantic version.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string // pre-release, like "rc.1"
+	Build               string // build metadata
+}
+
+// ParseVersion parses a semantic version, with an optional prefix "v".
+func ParseVersion(s string) (Version, error) {
+	m := reSemver.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version: %q", s)
+	}
+
+	v := Version{Pre: m[4], Build: m[5]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+// String returns the version, without the prefix "v".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 whether v has lower, equal or higher precedence
+// than w. The build metadata is not compared.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+
+	// Pre-releases are compared by their identifiers.
+	a, b := strings.Split(v.Pre, "."), strings.Split(w.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+
+		switch {
+		case errA == nil && errB == nil:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case errA == nil: // numeric identifiers have lower precedence
+			return -1
+		case errB == nil:
+			return 1
+		case a[i] < b[i]:
+			return -1
+		}
+		return 1
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// ReleaseOptions are the options to release a version.
+type ReleaseOptions struct {
+	Date string // by default, the current date, as YYYY-MM-DD
+	Tag  bool   // commit the changelog, and tag the version in the VCS
+}
+
+// Release moves the entries of the section "Unreleased" of the changelog of
+// the project in "dir" to a new section for the version, which has to be
+// higher than the released ones. Returns the name of the tag, like "v1.0.0".
+//
+// To tag the version, the tag must not exist and the changelog has to be the
+// only file changed; it is checked before of writing the changelog.
+func Release(dir, version string, opt ReleaseOptions) (tag string, err error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if opt.Date == "" {
+		opt.Date = time.Now().Format("2006-01-02")
+	} else if _, err = time.Parse("2006-01-02", opt.Date); err != nil {
+		return "", fmt.Errorf("invalid date, expected YYYY-MM-DD: %q", opt.Date)
+	}
+
+	var vcs VCS
+	if opt.Tag {
+		if vcs, err = releaseVCS(dir); err != nil {
+			return "", err
+		}
+	}
+
+	file := filepath.Join(dir, _CHANGELOG)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	// == Sections
+
+	unreleased, end, err := findUnreleased(lines)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		m := reChangelogSection.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		if w, err := ParseVersion(m[1]); err == nil {
+			switch v.Compare(w) {
+			case 0:
+				return "", fmt.Errorf("%s: version already released: %s", _CHANGELOG, w)
+			case -1:
+				return "", fmt.Errorf("%s: version %s is lower than the released %s",
+					_CHANGELOG, v, w)
+			}
+		}
+	}
+
+	entries := changelogEntries(lines[unreleased+1 : end])
+	if len(entries) == 0 {
+		return "", fmt.Errorf("%s: no unreleased changes", _CHANGELOG)
+	}
+
+	// == VCS
+
+	tag = "v" + v.String()
+	if opt.Tag {
+		if err = checkRelease(vcs, dir, tag); err != nil {
+			return "", err
+		}
+	}
+
+	// == Write
+
+	out := make([]string, 0, len(lines)+4)
+	out = append(out, lines[:unreleased+1]...)
+	out = append(out, "", fmt.Sprintf("## [%s] - %s", v, opt.Date), "")
+	out = append(out, entries...)
+	out = append(out, "") // blank line, or new line at the end of file
+	out = append(out, lines[end:]...)
+
+	if err = writeFile(file, []byte(strings.Join(out, "\n"))); err != nil {
+		return "", err
+	}
+
+	if opt.Tag {
+		if err = vcs.Commit(dir, []string{_CHANGELOG}, "Release "+tag, ""); err != nil {
+			// The changelog is restored to be released again.
+			if err2 := writeFile(file, data); err2 != nil {
+				return "", fmt.Errorf("%s; %s", err, err2)
+			}
+			return "", err
+		}
+		if err = vcs.(tagger).Tag(dir, tag, "Release "+tag); err != nil {
+			return "", fmt.Errorf("%s; the changelog has been committed", err)
+		}
+	}
+	return tag, nil
+}
+
+// checkRelease checks that the tag of the release does not exist, and that
+// the changelog is the only file with changes not committed, before of
+// writing it.
+func checkRelease(vcs VCS, dir, tag string) error {
+	found, err := vcs.(tagger).HasTag(dir, tag)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("tag: %s already exists", tag)
+	}
+
+	if l, ok := vcs.(changeLister); ok {
+		files, err := l.Changes(dir)
+		if err != nil {
+			return err
+		}
+		for _, v := range files {
+			if v != _CHANGELOG {
+				return fmt.Errorf("tag: there are changes not committed, like in %s", v)
+			}
+		}
+	}
+	return nil
+}
+
+// findUnreleased returns the line of the heading of the section "Unreleased",
+// and the line where it ends.
+func findUnreleased(lines []string) (start, end int, err error) {
+	start, end = -1, len(lines)
+
+	for i, line := range lines {
+		m := reChangelogSection.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if strings.EqualFold(m[1], _UNRELEASED) {
+			start = i
+		} else if start != -1 {
+			end = i
+			break
+		}
+	}
+	if start == -1 {
+		return 0, 0, fmt.Errorf("%s: section %q not found", _CHANGELOG, _UNRELEASED)
+	}
+	return start, end, nil
+}
+
+// changelogEntries returns the lines of a section, without the blank lines at
+// the start and end, and without the subsections which have no entries.
+func changelogEntries(lines []string) []string {
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "### ") {
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(lines[j], "### ") {
+				if strings.TrimSpace(lines[j]) != "" {
+					break
+				}
+				j++
+			}
+			if j == len(lines) || strings.HasPrefix(lines[j], "### ") {
+				i = j - 1 // empty subsection
+				continue
+			}
+		}
+		out = append(out, lines[i])
+	}
+
+	for len(out) != 0 && strings.TrimSpace(out[0]) == "" {
+		out = out[1:]
+	}
+	for len(out) != 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// releaseVCS returns the VCS of the repository in "dir", to tag a release.
+func releaseVCS(dir string) (VCS, error) {
+	name := DetectVCS(dir)
+	if name == "" {
+		return nil, errors.New("tag: no repository found")
+	}
+
+	v := vcsBackends[name]
+	if _, ok := v.(tagger); !ok {
+		return nil, fmt.Errorf("tag: not supported for %s", ListVCS[name])
+	}
+	return v, nil
+}
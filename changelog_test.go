@@ -0,0 +1,217 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"1.2.3", Version{1, 2, 3, "", ""}},
+		{"v0.1.0", Version{0, 1, 0, "", ""}},
+		{"1.0.0-rc.1", Version{1, 0, 0, "rc.1", ""}},
+		{"1.0.0-beta+exp.sha.5114f85", Version{1, 0, 0, "beta", "exp.sha.5114f85"}},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Errorf("%q: %s", tt.in, err)
+			continue
+		}
+		if v != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.in, v, tt.want)
+		}
+	}
+
+	for _, v := range []string{"1.0", "1.0.0.0", "01.0.0", "v", "1.0.0-", "vfoo", "1.0.0-01"} {
+		if _, err := ParseVersion(v); err == nil {
+			t.Errorf("%q: expected error", v)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Ordered by precedence, from semver.org.
+	list := []string{
+		"0.9.0",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i, a := range list {
+		va, _ := ParseVersion(a)
+		for j, b := range list {
+			vb, _ := ParseVersion(b)
+
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := va.Compare(vb); got != want {
+				t.Errorf("%s vs %s: got %d, want %d", a, b, got, want)
+			}
+		}
+	}
+
+	// The build metadata is not compared.
+	a, _ := ParseVersion("1.0.0+build.1")
+	b, _ := ParseVersion("1.0.0+build.2")
+	if a.Compare(b) != 0 {
+		t.Errorf("build metadata compared")
+	}
+}
+
+const testChangelog = `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- New flag.
+
+### Changed
+
+### Fixed
+
+- Crash on empty input.
+
+### Security
+
+## [0.1.0] - 2020-01-02
+
+### Added
+
+- First version.
+`
+
+func TestRelease(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, _CHANGELOG)
+	writeTestFile(t, file, testChangelog)
+
+	tag, err := Release(dir, "v0.2.0", ReleaseOptions{Date: "2021-03-04"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "v0.2.0" {
+		t.Errorf("tag: got %q", tag)
+	}
+
+	want := `# Changelog
+
+## [Unreleased]
+
+## [0.2.0] - 2021-03-04
+
+### Added
+
+- New flag.
+
+### Fixed
+
+- Crash on empty input.
+
+## [0.1.0] - 2020-01-02
+
+### Added
+
+- First version.
+`
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Errors
+	tests := []struct {
+		version string
+		msg     string
+	}{
+		{"0.3.0", "no unreleased changes"},
+		{"0.2.0", "version already released"},
+		{"0.1.5", "lower than the released"},
+		{"0.3", "invalid semantic version"},
+	}
+	for _, tt := range tests {
+		if _, err = Release(dir, tt.version, ReleaseOptions{}); err == nil ||
+			!strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: got error %v, want %q", tt.version, err, tt.msg)
+		}
+	}
+}
+
+func TestReleaseTag(t *testing.T) {
+	setGitIdentity(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, _CHANGELOG)
+
+	git(t, dir, "init", "-q")
+	writeTestFile(t, file, testChangelog)
+	writeTestFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "Add changelog")
+	git(t, dir, "tag", "-a", "v0.2.0", "-m", "Release v0.2.0")
+
+	// The changelog is not changed when the release can not be tagged.
+	tests := []struct {
+		version string
+		change  string
+		msg     string
+	}{
+		{"0.2.0", "", "v0.2.0 already exists"},
+		{"0.3.0", "package main // changed\n", "changes not committed, like in main.go"},
+	}
+	for _, tt := range tests {
+		if tt.change != "" {
+			writeTestFile(t, filepath.Join(dir, "main.go"), tt.change)
+		}
+		_, err := Release(dir, tt.version, ReleaseOptions{Tag: true})
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: got error %v, want %q", tt.version, err, tt.msg)
+		}
+		if data, _ := os.ReadFile(file); string(data) != testChangelog {
+			t.Errorf("%s: changelog changed:\n%s", tt.version, data)
+		}
+	}
+	git(t, dir, "checkout", "-q", "main.go")
+
+	// The changelog can have changes.
+	writeTestFile(t, file, strings.Replace(testChangelog, "- New flag.", "- New flags.", 1))
+
+	tag, err := Release(dir, "0.3.0", ReleaseOptions{Date: "2021-03-04", Tag: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, dir, "log", "-1", "--format=%s%d"); got != "Release v0.3.0 (HEAD -> "+
+		git(t, dir, "branch", "--show-current")+", tag: "+tag+")" {
+		t.Errorf("commit: got %q", got)
+	}
+	if got := git(t, dir, "status", "--porcelain"); got != "" {
+		t.Errorf("files not committed:\n%s", got)
+	}
+}
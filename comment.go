@@ -0,0 +1,121 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// CommentStyle is the way to comment the license header in a kind of file.
+// A block comment has start and end lines; then, Line is the prefix of the
+// lines into the block.
+type CommentStyle struct {
+	Start string
+	Line  string
+	End   string
+}
+
+// Comment styles
+var (
+	ListCommentStyleSorted = []string{"c", "dash", "hash", "html", "slash", "template"}
+
+	ListCommentStyle = map[string]CommentStyle{
+		"c":     {Start: "/*", Line: " *", End: " */"},
+		"dash":  {Line: "--"},
+		"hash":  {Line: "#"},
+		"html":  {Start: "<!--", End: "-->"},
+		"slash": {Line: "//"},
+
+		// Go templates; the spaces after of the comment are trimmed, so it
+		// is not in the output.
+		"template": {Start: "{{/*", End: "*/ -}}"},
+	}
+)
+
+// _COMMENT_STYLE is the comment style of Go files.
+const _COMMENT_STYLE = "slash"
+
+// commentExts are the comment styles by file extension.
+var commentExts = map[string]string{
+	// slash
+	".go":    "slash",
+	".proto": "slash",
+	".s":     "slash", // Go assembly
+	".js":    "slash",
+	".ts":    "slash",
+
+	// hash
+	".sh":         "hash",
+	".bash":       "hash",
+	".zsh":        "hash",
+	".py":         "hash",
+	".rb":         "hash",
+	".pl":         "hash",
+	".yaml":       "hash",
+	".yml":        "hash",
+	".toml":       "hash",
+	".mk":         "hash",
+	".dockerfile": "hash",
+
+	// dash
+	".sql": "dash",
+	".lua": "dash",
+
+	// c
+	".c":   "c",
+	".h":   "c",
+	".css": "c",
+
+	// html
+	".html":   "html",
+	".htm":    "html",
+	".gohtml": "html",
+	".xml":    "html",
+	".svg":    "html",
+
+	// template
+	".tmpl":   "template",
+	".gotmpl": "template",
+}
+
+// commentFiles are the comment styles by file name, for files without
+// extension.
+var commentFiles = map[string]string{
+	"Dockerfile":    "hash",
+	"Containerfile": "hash",
+	"Makefile":      "hash",
+	"GNUmakefile":   "hash",
+}
+
+// CommentStyleFor returns the comment style of the file "name" by its
+// extension, its name, or the shebang in its first line of data.
+// Returns false if the kind of file is unknown.
+func CommentStyleFor(name string, data []byte) (CommentStyle, bool) {
+	base := filepath.Base(name)
+
+	style, ok := commentExts[strings.ToLower(filepath.Ext(base))]
+	if !ok {
+		style, ok = commentFiles[base]
+	}
+	if !ok && strings.HasPrefix(base, "Dockerfile.") {
+		style, ok = "hash", true
+	}
+	if !ok && bytes.HasPrefix(data, []byte("#!")) {
+		style, ok = "hash", true
+	}
+	if !ok {
+		return CommentStyle{}, false
+	}
+	return ListCommentStyle[style], true
+}
+
+// setCommentStyle sets the comment style to render the license header.
+func (c *Conf) setCommentStyle(s CommentStyle) {
+	c.CommentStart, c.Comment, c.CommentEnd = s.Start, s.Line, s.End
+}
@@ -0,0 +1,75 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestCommentStyleFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		style string // empty if unknown
+	}{
+		{"main.go", "", "slash"},
+		{"api/v1.PROTO", "", "slash"},
+		{"run.sh", "", "hash"},
+		{"Makefile", "", "hash"},
+		{"Dockerfile.dev", "", "hash"},
+		{"script", "#!/bin/sh\n", "hash"},
+		{"schema.sql", "", "dash"},
+		{"style.css", "", "c"},
+		{"index.html", "", "html"},
+		{"page.gohtml", "", "html"},
+		{"mail.tmpl", "", "template"},
+		{"config.gotmpl", "", "template"},
+		{"README.md", "", ""},
+		{"data.bin", "\x00", ""},
+	}
+
+	for _, tt := range tests {
+		style, ok := CommentStyleFor(tt.name, []byte(tt.data))
+		if ok != (tt.style != "") || (ok && style != ListCommentStyle[tt.style]) {
+			t.Errorf("%s: got %+v, %v; want %q", tt.name, style, ok, tt.style)
+		}
+	}
+}
+
+func TestTemplateHeader(t *testing.T) {
+	setDataDir(t)
+	p := newTestProject(t, &Conf{})
+	p.parseLicense()
+
+	style := ListCommentStyle["template"]
+	header, err := p.renderHeader(style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := insertHeader([]byte("Hello, {{.}}!\n"), header)
+
+	// The header is a comment of the template, which is not in the output.
+	tmpl, err := template.New("test").Parse(string(data))
+	if err != nil {
+		t.Fatalf("%s\n%s", err, data)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, "World"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "Hello, World!\n" {
+		t.Errorf("got output %q", buf.String())
+	}
+
+	lines, start, end := findHeader(data, style)
+	if got := strings.Join(lines[start:end], ""); got != string(header) {
+		t.Errorf("header found:\n%s\nwant:\n%s", got, header)
+	}
+}
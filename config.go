@@ -13,31 +13,73 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"text/template"
 
 	"github.com/tredoe/dat/valid"
-	"gopkg.in/yaml.v1"
 )
 
 // Conf represents the configuration of the project.
 type Conf struct {
 	Project     string
 	Program     string // to lower case
+	Kind        string // layout of the project; by default, "library"
 	License     string
 	Author      string
 	Email       string
 	VCS         string
 	Org         string // the author develops the program for an organization
-	Import      string // To get data from user configuration; then is sent to ImportPaths
 	ImportPaths []string
+	Modules     []string // directories of modules, to create a workspace
+	Ignore      []string // fragments of the ignore file
+	Generated   []string // patterns of generated files
+	Binary      []string // patterns of binary files
+	Reuse       bool     // follow the REUSE specification
+
+	// Policy of the years of the copyright: "fixed", the year of creation; or
+	// "vcs", the range of years of the changes in the VCS history.
+	CopyrightYears string
+
+	Readme string // format of the readme file: "markdown", by default, or "asciidoc"
+
+	// Repository
+	Branch       string // default branch
+	Remote       string // URL of the remote repository; "$" is the program name
+	Commit       bool   `json:"-"` // commit the files after of initializing the VCS
+	CommitMsg    string `json:"-"`
+	CommitAuthor string `json:"-"` // like "Name <email>"; by default, Author and Email
+
+	EmailStyle map[string]string // style to render the email, by output file
+
+	Authors      []Person // copyright holders; by default, Org or Author
+	Contributors []Person // by default, Author
+
+	Profile  string              // name of the profile used
+	Profiles map[string]*Profile `json:"-"` // defined in configuration files
 
 	// To pass to templates
 	ImportPath    string
-	Comment       string
+	Comment       string // prefix of the lines of the license header
+	CommentStart  string `json:"-"` // line to start a block comment
+	CommentEnd    string `json:"-"`
 	FullLicense   string
+	FullLicenses  []string // several ones for a dual license
+	SPDX          string   // license expression of SPDX
 	GNUextra      string
 	ProjectHeader string
 	Year          int
+	LastYear      int `json:"-"` // year of the last change, for a range of years
+	GoVersion     string
+
+	// Origins has the source of the default values, by field name in lower
+	// case; i.e. the user configuration file or the VCS configuration.
+	Origins map[string]string `json:"-" yaml:"-"`
+}
+
+// setOrigin records the source of the default value for the field.
+func (c *Conf) setOrigin(field, source string) {
+	if c.Origins == nil {
+		c.Origins = make(map[string]string)
+	}
+	c.Origins[field] = source
 }
 
 // SetNames sets names for both project and program.
@@ -69,79 +111,302 @@ func (c *Conf) SetNames() error {
 //
 // == User configuration
 
-// AddConfig creates the user configuration file.
-func (cfg *Conf) AddConfig() error {
-	tmpl := template.Must(template.New("Config").Parse(tmplUserConfig))
+// Configuration keys, which can be set in configuration files and environment
+// variables.
+var ListConfigKeys = []string{"org", "author", "email", "license", "vcs", "import",
+	"branch", "remote", "ignore", "generated", "binary", "copyright_years", "readme"}
+
+// get returns the value of the configuration key.
+func (c *Conf) get(key string) string {
+	switch key {
+	case "org":
+		return c.Org
+	case "author":
+		return c.Author
+	case "email":
+		return c.Email
+	case "license":
+		return c.License
+	case "vcs":
+		return c.VCS
+	case "import":
+		return strings.Join(c.ImportPaths, ":")
+	case "branch":
+		return c.Branch
+	case "remote":
+		return c.Remote
+	case "ignore":
+		return strings.Join(c.Ignore, ",")
+	case "generated":
+		return strings.Join(c.Generated, ",")
+	case "binary":
+		return strings.Join(c.Binary, ",")
+	case "copyright_years":
+		return c.CopyrightYears
+	case "readme":
+		return c.Readme
+	}
+	return ""
+}
 
-	home := os.Getenv("HOME")
-	if home == "" {
-		return errors.New("could not add user configuration file because $HOME is not set")
+// set sets the value of the configuration key.
+func (c *Conf) set(key, value string) {
+	switch key {
+	case "org":
+		c.Org = value
+	case "author":
+		c.Author = value
+	case "email":
+		c.Email = value
+	case "license":
+		c.License = value
+	case "vcs":
+		c.VCS = value
+	case "import":
+		c.ImportPaths = strings.Split(value, ":")
+	case "branch":
+		c.Branch = value
+	case "remote":
+		c.Remote = value
+	case "ignore":
+		c.Ignore = strings.Split(value, ",")
+	case "generated":
+		c.Generated = strings.Split(value, ",")
+	case "binary":
+		c.Binary = strings.Split(value, ",")
+	case "copyright_years":
+		c.CopyrightYears = value
+	case "readme":
+		c.Readme = value
 	}
+}
 
-	file, err := createFile(filepath.Join(home, _USER_CONFIG))
-	if err != nil {
-		return err
+// Get returns the value of the configuration key, and where it comes from.
+func (c *Conf) Get(key string) (value, origin string) {
+	return c.get(key), c.Origins[key]
+}
+
+// merge sets the values not set yet from the configuration cfg, whose values
+// come from "origin".
+func (c *Conf) merge(cfg *Conf, origin string) {
+	for _, k := range ListConfigKeys {
+		if c.get(k) == "" {
+			if v := cfg.get(k); v != "" {
+				c.set(k, v)
+				c.setOrigin(k, origin)
+			}
+		}
 	}
 
-	cfg.ImportPath = strings.Join(cfg.ImportPaths, ":")
+	if len(c.Authors) == 0 && len(cfg.Authors) != 0 {
+		c.Authors = cfg.Authors
+		c.setOrigin("authors", origin)
+	}
+	if len(c.Contributors) == 0 && len(cfg.Contributors) != 0 {
+		c.Contributors = cfg.Contributors
+		c.setOrigin("contributors", origin)
+	}
 
-	if err := tmpl.Execute(file, cfg); err != nil {
-		return fmt.Errorf("execution failed: %s", err)
+	for k, v := range cfg.EmailStyle {
+		if _, ok := c.EmailStyle[k]; !ok {
+			if c.EmailStyle == nil {
+				c.EmailStyle = make(map[string]string)
+			}
+			c.EmailStyle[k] = v
+			c.setOrigin("email_style."+k, origin)
+		}
 	}
-	return nil
 }
 
-// UserConfig loads configuration per user, if any.
-func (c *Conf) UserConfig() error {
-	home := os.Getenv("HOME")
-	if home == "" {
-		return fmt.Errorf("environment variable $HOME is not set")
+// userConfigFile returns the path of the user configuration file, in the XDG
+// configuration directory.
+func userConfigFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return "", errors.New("neither $XDG_CONFIG_HOME nor $HOME are set")
+		}
+		dir = filepath.Join(home, ".config")
 	}
+	return filepath.Join(dir, _CONFIG_DIR, _CONFIG_FILE), nil
+}
+
+// configFiles returns the configuration files, from the highest precedence to
+// the lowest: project, user and system. The files could not exist.
+func configFiles() []string {
+	files := make([]string, 0)
 
-	pathUserConfig := filepath.Join(home, _USER_CONFIG)
+	if dir := findUp(".", _PROJECT_CONFIG); dir != "" {
+		files = append(files, filepath.Join(dir, _PROJECT_CONFIG))
+	}
 
-	// To know if the file exist.
-	switch info, err := os.Stat(pathUserConfig); {
-	case os.IsNotExist(err):
-		return nil
-	case !info.Mode().IsRegular():
-		return fmt.Errorf("expected regular file: %s", _USER_CONFIG)
+	if file, err := userConfigFile(); err == nil {
+		files = append(files, file)
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		files = append(files, filepath.Join(home, _USER_CONFIG))
+	}
+
+	dirs := os.Getenv("XDG_CONFIG_DIRS")
+	if dirs == "" {
+		dirs = "/etc/xdg"
+	}
+	for _, v := range filepath.SplitList(dirs) {
+		if v != "" {
+			files = append(files, filepath.Join(v, _CONFIG_DIR, _CONFIG_FILE))
+		}
 	}
 
-	data, err := os.ReadFile(pathUserConfig)
+	return files
+}
+
+// AddConfig creates the user configuration file, migrating the old one.
+// The profiles of the file, if it exists, are kept.
+func (cfg *Conf) AddConfig() error {
+	pathUserConfig, err := userConfigFile()
 	if err != nil {
+		return fmt.Errorf("could not add user configuration file: %s", err)
+	}
+
+	if _, err = MigrateConfig(); err != nil {
 		return err
 	}
 
-	cfg := Conf{}
-	if err = yaml.Unmarshal([]byte(data), &cfg); err != nil {
-		return fmt.Errorf("error parsing configuration: %s", err)
+	file := &configFile{}
+	if data, err := os.ReadFile(pathUserConfig); err == nil {
+		if file, _, err = loadConfigFile(pathUserConfig, data); err != nil {
+			return err
+		}
+	}
+
+	file.Version = _CONFIG_VERSION
+	file.Org = cfg.Org
+	file.Author = cfg.Author
+	file.Email = cfg.Email
+	file.License = cfg.License
+	file.VCS = cfg.VCS
+	file.Import = cfg.ImportPaths
+	file.Branch = cfg.Branch
+	file.Remote = cfg.Remote
+	file.Ignore = cfg.Ignore
+	file.Generated = cfg.Generated
+	file.Binary = cfg.Binary
+	file.CopyrightYears = cfg.CopyrightYears
+	file.Readme = cfg.Readme
+	if len(cfg.EmailStyle) != 0 {
+		file.EmailStyle = cfg.EmailStyle
 	}
 
-	// == Get values
+	return writeConfigFile(pathUserConfig, file)
+}
 
-	if c.Org == "" && cfg.Org != "" {
-		c.Org = cfg.Org
+// UserConfig loads the configuration, setting the values which have not been
+// set by flags. The precedence, from the highest to the lowest, is:
+//
+//   - flags
+//   - environment variables, like GOWIZARD_AUTHOR
+//   - project file, ".gowizard.yaml" in the directory or a parent one
+//   - user file, "$XDG_CONFIG_HOME/gowizard/config.yaml", or "~/.gowizard"
+//   - system files, "gowizard/config.yaml" in $XDG_CONFIG_DIRS
+//   - VCS configuration, for author and email
+//
+// In every file, the values of the profile used have precedence over the rest.
+// The profile is set by the field Profile, or by the environment variable
+// GOWIZARD_PROFILE; else it is the one which matches the current directory or
+// the import path.
+//
+// The source of every value is stored in the field Origins.
+func (c *Conf) UserConfig() error {
+	for _, k := range ListConfigKeys {
+		if c.get(k) != "" {
+			c.setOrigin(k, "flag")
+		}
 	}
-	if c.Author == "" && cfg.Author != "" {
-		c.Author = cfg.Author
+	for k := range c.EmailStyle {
+		c.setOrigin("email_style."+k, "flag")
 	}
-	if c.Email == "" && cfg.Email != "" {
-		c.Email = cfg.Email
+	if c.Profile != "" {
+		c.setOrigin("profile", "flag")
 	}
-	if c.License == "" && cfg.License != "" {
-		c.License = cfg.License
+
+	// == Environment variables
+
+	for _, k := range ListConfigKeys {
+		env := _ENV_PREFIX + strings.ToUpper(k)
+
+		if v := os.Getenv(env); v != "" && c.get(k) == "" {
+			c.set(k, v)
+			c.setOrigin(k, "$"+env)
+		}
 	}
-	if c.VCS == "" && cfg.VCS != "" {
-		c.VCS = cfg.VCS
+
+	// == Files
+
+	layers, err := readConfigLayers()
+	if err != nil {
+		return err
 	}
-	if len(c.ImportPaths) == 0 && cfg.Import != "" {
-		c.ImportPaths = strings.Split(cfg.Import, ":")
+	if err = c.selectProfile(layers); err != nil {
+		return err
+	}
+
+	for _, l := range layers {
+		if p, ok := l.cfg.Profiles[c.Profile]; ok {
+			c.merge(p.conf(), l.file+" (profile "+c.Profile+")")
+		}
+		c.merge(l.cfg, l.file)
 	}
 
+	c.vcsConfig()
 	return nil
 }
 
+// vcsConfig sets the author, email and organization from the configuration of
+// the VCS, if they have not been set. When the VCS is not set, they are looked
+// for in the VCS of the current repository, if any, and then in Git,
+// Mercurial, Jujutsu and Bazaar, in that order.
+func (c *Conf) vcsConfig() {
+	if c.Author != "" && c.Email != "" && c.Org != "" {
+		return
+	}
+
+	vcsList := []string{"git", "hg", "jj", "bzr"}
+	if _, ok := listConfigVCSLocal[strings.ToLower(c.VCS)]; ok {
+		vcsList = []string{strings.ToLower(c.VCS)}
+	} else if vcs := DetectVCS("."); vcs != "" {
+		for i, v := range vcsList {
+			if v == vcs {
+				copy(vcsList[1:i+1], vcsList[:i])
+				vcsList[0] = vcs
+			}
+		}
+	}
+
+	for _, vcs := range vcsList {
+		user, found := readVCSUser(vcs, ".")
+		if !found {
+			continue
+		}
+
+		if c.Author == "" && user.name != "" {
+			c.Author = user.name
+			c.setOrigin("author", user.nameSource)
+		}
+		if c.Email == "" && user.email != "" {
+			c.Email = user.email
+			c.setOrigin("email", user.emailSource)
+		}
+		if c.Org == "" && user.org != "" {
+			c.Org = user.org
+			c.setOrigin("org", user.orgSource)
+		}
+		if c.Author != "" && c.Email != "" && c.Org != "" {
+			return
+		}
+	}
+}
+
 // == Checking
 //
 
@@ -175,13 +440,62 @@ func (c *Conf) PreCheck(interactive, addConfig bool) error {
 
 	// License
 	if c.License != "" {
-		c.License = strings.ToLower(c.License)
+		license, err := checkLicense(c.License)
+		if err != nil {
+			return err
+		}
+		c.License = license
+	}
 
-		if _, ok := ListLowerLicense[c.License]; !ok {
-			return fmt.Errorf("unavailable license: %q", c.License)
+	// Copyright years
+	if c.CopyrightYears != "" {
+		c.CopyrightYears = strings.ToLower(c.CopyrightYears)
+
+		if _, ok := ListCopyrightYears[c.CopyrightYears]; !ok {
+			return fmt.Errorf("unavailable policy of copyright years: %q; valid: %s",
+				c.CopyrightYears, strings.Join(sortedKeys(ListCopyrightYears), ", "))
+		}
+	}
+
+	// Readme
+	if c.Readme != "" {
+		c.Readme = strings.ToLower(c.Readme)
+
+		if _, ok := ListReadme[c.Readme]; !ok {
+			return fmt.Errorf("unavailable format of readme: %q; valid: %s",
+				c.Readme, strings.Join(ListReadmeSorted, ", "))
+		}
+	}
+
+	// Kind
+	if c.Kind != "" {
+		c.Kind = strings.ToLower(c.Kind)
+
+		if _, ok := ListKind[c.Kind]; !ok {
+			return fmt.Errorf("unavailable kind: %q", c.Kind)
+		}
+	}
+
+	// Modules
+	for i, v := range c.Modules {
+		if err := checkModule(v, c.Kind); err != nil {
+			return err
+		}
+		for _, v2 := range c.Modules[:i] {
+			if v == v2 {
+				return fmt.Errorf("duplicated module: %q", v)
+			}
 		}
 	}
 
+	// Ignore file
+	for i, v := range c.Ignore {
+		c.Ignore[i] = strings.ToLower(strings.TrimSpace(v))
+	}
+	if err := checkIgnore(c.Ignore); err != nil {
+		return err
+	}
+
 	// VCS
 	if c.VCS != "" {
 		c.VCS = strings.ToLower(c.VCS)
@@ -206,16 +520,21 @@ func (c *Conf) PostCheck(interactive, addConfig bool) error {
 		}
 	}
 
-	c.Email = fmt.Sprintf("%s <%s>",
-		c.Author, strings.Replace(c.Email, "@", " AT ", -1))
+	for k, v := range c.EmailStyle {
+		if err := checkEmailStyle(k, v); err != nil {
+			return err
+		}
+	}
 
 	// Adds extra fields to pass to templates.
 	if !addConfig {
+		if err := c.checkRepository(); err != nil {
+			return err
+		}
+
 		c.ProjectHeader = strings.Repeat(_HEADER_CHAR, len(c.Project))
 
-		if c.License != "none" {
-			c.FullLicense = ListLicense[ListLowerLicense[c.License]]
-		}
+		c.setLicenseNames()
 	}
 
 	return nil
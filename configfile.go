@@ -0,0 +1,514 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tredoe/dat/valid"
+	"gopkg.in/yaml.v3"
+)
+
+// _CONFIG_VERSION is the version of the format of the configuration files.
+//
+//   - 1: without key "version"; "import" is a colon-separated list.
+//   - 2: key "version"; "import" is a list of YAML.
+const _CONFIG_VERSION = 2
+
+// configFile is the format of the configuration files.
+type configFile struct {
+	Version  int                 `yaml:"version"`
+	Org      string              `yaml:"org,omitempty"`
+	Author   string              `yaml:"author,omitempty"`
+	Email    string              `yaml:"email,omitempty"`
+	License  string              `yaml:"license,omitempty"`
+	VCS      string              `yaml:"vcs,omitempty"`
+	Import   []string            `yaml:"import,omitempty"`
+	Branch   string              `yaml:"branch,omitempty"`
+	Remote   string              `yaml:"remote,omitempty"`
+	Ignore   []string            `yaml:"ignore,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+
+	EmailStyle map[string]string `yaml:"email_style,omitempty"`
+
+	Generated []string `yaml:"generated,omitempty"`
+	Binary    []string `yaml:"binary,omitempty"`
+
+	CopyrightYears string `yaml:"copyright_years,omitempty"`
+	Readme         string `yaml:"readme,omitempty"`
+
+	Authors      []Person `yaml:"authors,omitempty"`
+	Contributors []Person `yaml:"contributors,omitempty"`
+}
+
+// Keys allowed in the configuration files.
+var (
+	configFileKeys = []string{"version", "org", "author", "email", "license",
+		"vcs", "import", "branch", "remote", "ignore", "generated", "binary",
+		"copyright_years", "readme", "profiles", "email_style", "authors", "contributors"}
+	configPersonKeys  = []string{"name", "email", "org"}
+	configProfileKeys = []string{"org", "author", "email", "license", "vcs",
+		"import", "branch", "remote", "ignore", "generated", "binary",
+		"copyright_years", "readme", "email_style", "match"}
+
+	// Keys of the file which are not allowed in profiles, since they are not
+	// values to switch by project.
+	configFileOnlyKeys = []string{"version", "profiles", "authors", "contributors"}
+)
+
+// conf returns the configuration values of the file.
+func (f *configFile) conf() *Conf {
+	return &Conf{
+		Org:         f.Org,
+		Author:      f.Author,
+		Email:       f.Email,
+		License:     f.License,
+		VCS:         f.VCS,
+		ImportPaths: f.Import,
+		Branch:      f.Branch,
+		Remote:      f.Remote,
+		Ignore:      f.Ignore,
+		Generated:   f.Generated,
+		Binary:      f.Binary,
+		Profiles:    f.Profiles,
+
+		CopyrightYears: f.CopyrightYears,
+		Readme:         f.Readme,
+		EmailStyle:     f.EmailStyle,
+
+		Authors:      f.Authors,
+		Contributors: f.Contributors,
+	}
+}
+
+// ConfigError represents an error in a configuration file.
+type ConfigError struct {
+	File   string
+	Line   int // 0 if the position is unknown
+	Column int
+	Msg    string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// nodeError returns an error at the position of the node.
+func nodeError(file string, n *yaml.Node, format string, a ...interface{}) error {
+	return &ConfigError{file, n.Line, n.Column, fmt.Sprintf(format, a...)}
+}
+
+// loadConfigFile reads and checks the configuration file "name".
+// The files in an old format are converted to the current one, and it is
+// returned migrated as true.
+func loadConfigFile(name string, data []byte) (cfg *configFile, migrated bool, err error) {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, &ConfigError{File: name, Msg: err.Error()}
+	}
+
+	cfg = new(configFile)
+	if len(doc.Content) == 0 {
+		cfg.Version = _CONFIG_VERSION
+		return cfg, false, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, false, nodeError(name, root, "expected a mapping of keys")
+	}
+
+	// == Version
+
+	version := 1
+	if n := mappingValue(root, "version"); n != nil {
+		if err = n.Decode(&version); err != nil || version < 1 {
+			return nil, false, nodeError(name, n, "invalid version: %q", n.Value)
+		}
+		if version > _CONFIG_VERSION {
+			return nil, false, nodeError(name, n,
+				"unsupported version %d; upgrade Gowizard", version)
+		}
+	}
+
+	// == Keys
+
+	if err = checkKeys(name, root, configFileKeys); err != nil {
+		return nil, false, err
+	}
+	mappings := []*yaml.Node{root}
+
+	if profiles := mappingValue(root, "profiles"); profiles != nil {
+		if profiles.Kind != yaml.MappingNode {
+			return nil, false, nodeError(name, profiles, "expected a mapping of profiles")
+		}
+		for i := 1; i < len(profiles.Content); i += 2 {
+			p := profiles.Content[i]
+
+			if p.Kind != yaml.MappingNode {
+				return nil, false, nodeError(name, p, "expected a mapping of keys")
+			}
+			for j := 0; j+1 < len(p.Content); j += 2 {
+				for _, k := range configFileOnlyKeys {
+					if p.Content[j].Value == k {
+						return nil, false, nodeError(name, p.Content[j],
+							"key %q is not allowed in profiles; only in the top level of the file", k)
+					}
+				}
+			}
+			if err = checkKeys(name, p, configProfileKeys); err != nil {
+				return nil, false, err
+			}
+			mappings = append(mappings, p)
+		}
+	}
+
+	for _, k := range []string{"authors", "contributors"} {
+		list := mappingValue(root, k)
+		if list == nil {
+			continue
+		}
+		if list.Kind != yaml.SequenceNode {
+			return nil, false, nodeError(name, list, "expected a list of persons")
+		}
+		for _, p := range list.Content {
+			if p.Kind != yaml.MappingNode {
+				return nil, false, nodeError(name, p, "expected a mapping of keys")
+			}
+			if err = checkKeys(name, p, configPersonKeys); err != nil {
+				return nil, false, err
+			}
+			mappings = append(mappings, p)
+		}
+	}
+
+	// == Migration
+
+	if version == 1 {
+		// The import paths were a colon-separated list.
+		for _, m := range mappings {
+			if n := mappingValue(m, "import"); n != nil && n.Kind == yaml.ScalarNode {
+				seq := &yaml.Node{Kind: yaml.SequenceNode, Line: n.Line, Column: n.Column}
+				for _, v := range strings.Split(n.Value, ":") {
+					if v = strings.TrimSpace(v); v != "" {
+						seq.Content = append(seq.Content,
+							&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+					}
+				}
+				*n = *seq
+			}
+		}
+		// The email was stored obfuscated, like "Name <user AT host>".
+		for _, m := range mappings {
+			if n := mappingValue(m, "email"); n != nil && n.Kind == yaml.ScalarNode &&
+				strings.Contains(n.Value, " AT ") {
+				n.Value = unobfuscateEmail(n.Value)
+			}
+		}
+	}
+
+	if err = root.Decode(cfg); err != nil {
+		return nil, false, decodeError(name, err)
+	}
+
+	for _, m := range mappings {
+		styles := mappingValue(m, "email_style")
+		if styles == nil {
+			continue
+		}
+		for i := 0; i+1 < len(styles.Content); i += 2 {
+			k, v := styles.Content[i], styles.Content[i+1]
+
+			if err = checkEmailStyle(k.Value, v.Value); err != nil {
+				return nil, false, nodeError(name, k, "%s", err)
+			}
+		}
+	}
+
+	// == Values
+
+	for _, m := range mappings {
+		if err = checkValues(name, m); err != nil {
+			return nil, false, err
+		}
+	}
+
+	cfg.Version = _CONFIG_VERSION
+	return cfg, version != _CONFIG_VERSION, nil
+}
+
+// decodeError returns the first error got decoding the file, with its line.
+func decodeError(file string, err error) error {
+	te, ok := err.(*yaml.TypeError)
+	if !ok || len(te.Errors) == 0 {
+		return &ConfigError{File: file, Msg: err.Error()}
+	}
+
+	e := &ConfigError{File: file, Msg: te.Errors[0]}
+	if n, _ := fmt.Sscanf(te.Errors[0], "line %d:", &e.Line); n == 1 {
+		e.Msg = strings.TrimSpace(te.Errors[0][strings.IndexByte(te.Errors[0], ':')+1:])
+		e.Column = 1
+	}
+	return e
+}
+
+// mappingValue returns the value of the key in the mapping node, or nil if it
+// is not found.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkKeys checks that the keys of the mapping node are in "known".
+func checkKeys(file string, m *yaml.Node, known []string) error {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key := m.Content[i]
+
+		found := false
+		for _, v := range known {
+			if key.Value == v {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		if v := closestKey(key.Value, known); v != "" {
+			return nodeError(file, key, "unknown key %q; did you mean %q?", key.Value, v)
+		}
+		return nodeError(file, key, "unknown key %q; valid keys: %s",
+			key.Value, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// closestKey returns the key of "known" which is nearer to "key", ignoring
+// the case, or an empty string if none is near enough.
+func closestKey(key string, known []string) string {
+	key = strings.ToLower(key)
+	best, bestDist := "", 3
+
+	for _, v := range known {
+		if d := editDistance(key, v); d < bestDist {
+			best, bestDist = v, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if v := prev[j] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// checkValues checks the license, VCS and email of the mapping node.
+func checkValues(file string, m *yaml.Node) error {
+	if n := mappingValue(m, "license"); n != nil && n.Value != "" {
+		if _, err := checkLicense(n.Value); err != nil {
+			return nodeError(file, n, "%s", err)
+		}
+	}
+	if n := mappingValue(m, "vcs"); n != nil && n.Value != "" {
+		if _, ok := ListVCS[strings.ToLower(n.Value)]; !ok {
+			return nodeError(file, n, "unavailable VCS %q; valid: %s",
+				n.Value, strings.Join(sortedKeys(ListVCS), ", "))
+		}
+	}
+	if n := mappingValue(m, "ignore"); n != nil && n.Kind == yaml.SequenceNode {
+		for _, v := range n.Content {
+			if err := checkIgnore([]string{strings.ToLower(v.Value)}); err != nil {
+				return nodeError(file, v, "%s", err)
+			}
+		}
+	}
+	if n := mappingValue(m, "copyright_years"); n != nil && n.Value != "" {
+		if _, ok := ListCopyrightYears[strings.ToLower(n.Value)]; !ok {
+			return nodeError(file, n, "unavailable policy of copyright years %q; valid: %s",
+				n.Value, strings.Join(sortedKeys(ListCopyrightYears), ", "))
+		}
+	}
+	if n := mappingValue(m, "readme"); n != nil && n.Value != "" {
+		if _, ok := ListReadme[strings.ToLower(n.Value)]; !ok {
+			return nodeError(file, n, "unavailable format of readme %q; valid: %s",
+				n.Value, strings.Join(ListReadmeSorted, ", "))
+		}
+	}
+	if n := mappingValue(m, "email"); n != nil && n.Value != "" {
+		if _, err := valid.Email().Check(n.Value); err != nil {
+			return nodeError(file, n, "invalid email %q: %s", n.Value, err)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readConfigFile reads the configuration file. Returns nil if it does not
+// exist.
+//
+// The files in an old format are converted in memory; they are only rewritten
+// by MigrateConfig.
+func readConfigFile(name string) (*Conf, error) {
+	cfg, _, err := readConfigFileFormat(name)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return cfg.conf(), nil
+}
+
+// readConfigFileFormat reads the configuration file, returning its content
+// in the current format, and whether it was in an old one. Returns nil if it
+// does not exist.
+func readConfigFileFormat(name string) (cfg *configFile, migrated bool, err error) {
+	// To know if the file exist.
+	switch info, err := os.Stat(name); {
+	case os.IsNotExist(err):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, err
+	case !info.Mode().IsRegular():
+		return nil, false, fmt.Errorf("expected regular file: %s", name)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, false, err
+	}
+	return loadConfigFile(name, data)
+}
+
+// writeConfigFile writes the configuration to the file "name".
+func writeConfigFile(name string, cfg *configFile) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return writeFile(name, data)
+}
+
+// MigrateConfig converts the user configuration to the current format:
+//
+//   - the file in the old location, "~/.gowizard", is moved to the XDG one,
+//     if there is not a file yet; the old file is kept with the extension
+//     ".bak"
+//   - the file in an old format is rewritten in the current one, keeping a
+//     copy of the old file with the extension ".bak"
+//
+// Returns the files written.
+func MigrateConfig() ([]string, error) {
+	written := make([]string, 0)
+
+	newFile, err := migrateOldConfig()
+	if err != nil {
+		return nil, err
+	}
+	if newFile != "" {
+		written = append(written, newFile)
+	}
+
+	name, err := userConfigFile()
+	if err != nil {
+		return written, nil
+	}
+	cfg, migrated, err := readConfigFileFormat(name)
+	if err != nil || !migrated {
+		return written, err
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(name+".bak", data, _FILE_PERM); err != nil {
+		return nil, fmt.Errorf("could not migrate configuration: %s", err)
+	}
+	if err = writeConfigFile(name, cfg); err != nil {
+		return nil, fmt.Errorf("could not migrate configuration: %s", err)
+	}
+	return append(written, name), nil
+}
+
+// migrateOldConfig moves the user configuration file from its old location
+// in the home directory to the XDG one, if there is not a file yet.
+// The old file is kept with the extension ".bak".
+// Returns the new file, or an empty string if it has not been moved.
+func migrateOldConfig() (string, error) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", nil
+	}
+	oldFile := filepath.Join(home, _USER_CONFIG)
+
+	newFile, err := userConfigFile()
+	if err != nil {
+		return "", nil
+	}
+	if _, err = os.Stat(newFile); err == nil {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(oldFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	cfg, _, err := loadConfigFile(oldFile, data)
+	if err != nil {
+		return "", err
+	}
+	if err = writeConfigFile(newFile, cfg); err != nil {
+		return "", fmt.Errorf("could not migrate configuration: %s", err)
+	}
+	return newFile, os.Rename(oldFile, oldFile+".bak")
+}
@@ -0,0 +1,229 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setConfigHome sets a home and an XDG configuration directory in temporary
+// directories, and returns the home.
+func setConfigHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(home, "xdg"))
+	return home
+}
+
+// writeTestFile writes the file "name", creating its directory.
+func writeTestFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), _DIR_PERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), _FILE_PERM); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testOldConfig = `org:
+author: Jane Doe
+email: jane@example.com
+license: mpl
+vcs: git
+import: github.com/jane:example.com/jane
+`
+
+func TestReadConfigNoMigration(t *testing.T) {
+	home := setConfigHome(t)
+	oldFile := filepath.Join(home, _USER_CONFIG)
+	writeTestFile(t, oldFile, testOldConfig)
+
+	layers, err := readConfigLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 1 || layers[0].file != oldFile {
+		t.Fatalf("layers: got %v, want the file %s", layers, oldFile)
+	}
+	if got := layers[0].cfg.ImportPaths; len(got) != 2 || got[1] != "example.com/jane" {
+		t.Errorf("import: got %q", got)
+	}
+
+	// Reading must not touch the files.
+	data, err := os.ReadFile(oldFile)
+	if err != nil || string(data) != testOldConfig {
+		t.Errorf("old file changed: %q, %v", data, err)
+	}
+	for _, v := range []string{oldFile + ".bak", filepath.Join(home, ".config", _CONFIG_DIR, _CONFIG_FILE)} {
+		if _, err = os.Stat(v); !os.IsNotExist(err) {
+			t.Errorf("file %s written reading the configuration", v)
+		}
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	home := setConfigHome(t)
+	oldFile := filepath.Join(home, _USER_CONFIG)
+	newFile := filepath.Join(home, ".config", _CONFIG_DIR, _CONFIG_FILE)
+	writeTestFile(t, oldFile, testOldConfig)
+
+	files, err := MigrateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != newFile {
+		t.Errorf("files: got %q, want %q", files, newFile)
+	}
+	if _, err = os.Stat(oldFile + ".bak"); err != nil {
+		t.Errorf("old file not kept: %s", err)
+	}
+
+	cfg, migrated, err := readConfigFileFormat(newFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated || cfg.Author != "Jane Doe" || len(cfg.Import) != 2 {
+		t.Errorf("got migrated=%v, %+v", migrated, cfg)
+	}
+
+	// Nothing to do the next time.
+	if files, err = MigrateConfig(); err != nil || len(files) != 0 {
+		t.Errorf("second migration: got %q, %v", files, err)
+	}
+}
+
+func TestLoadLegacyEmail(t *testing.T) {
+	home := setConfigHome(t)
+	t.Setenv(_ENV_PREFIX+"EMAIL", "")
+
+	// As it was written by the old command to add the user configuration.
+	writeTestFile(t, filepath.Join(home, _USER_CONFIG), `org: 
+author: Jane Doe
+email: Jane Doe <jane AT example.com>
+license: mpl
+vcs: git
+import: github.com/jane
+`)
+
+	cfg := new(Conf)
+	if err := cfg.UserConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Email != "jane@example.com" {
+		t.Errorf("email: got %q, want %q", cfg.Email, "jane@example.com")
+	}
+
+	if _, err := MigrateConfig(); err != nil {
+		t.Fatal(err)
+	}
+	file, _, err := readConfigFileFormat(filepath.Join(home, ".config", _CONFIG_DIR, _CONFIG_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Email != "jane@example.com" {
+		t.Errorf("migrated email: got %q", file.Email)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+		msg  string
+	}{
+		{"unknown key", "version: 2\nauthor: Jane\nlicence: mpl\n",
+			3, `unknown key "licence"; did you mean "license"?`},
+		{"unknown key far", "version: 2\nfoo: bar\n",
+			2, `unknown key "foo"; valid keys: `},
+		{"unknown key in profile", "version: 2\nprofiles:\n  work:\n    org: Acme\n    emial: jane@acme.com\n",
+			5, `unknown key "emial"; did you mean "email"?`},
+		{"file key in profile", "version: 2\nprofiles:\n  work:\n    org: Acme\n    authors: []\n",
+			5, `key "authors" is not allowed in profiles`},
+		{"email style in profile", "version: 2\nprofiles:\n  work:\n    email_style:\n      header: hidden\n",
+			5, `unavailable email style: "hidden"`},
+		{"unknown key in person", "version: 2\nauthors:\n  - name: Jane\n    mail: jane@acme.com\n",
+			4, `unknown key "mail"; did you mean "email"?`},
+		{"license", "version: 2\nlicense: bsd\n",
+			2, `unavailable license: "bsd"`},
+		{"vcs", "version: 2\nvcs: cvs\n",
+			2, `unavailable VCS "cvs"`},
+		{"readme", "version: 2\nreadme: rst\n",
+			2, `unavailable format of readme "rst"`},
+		{"copyright years", "version: 2\ncopyright_years: all\n",
+			2, `unavailable policy of copyright years "all"`},
+		{"email style", "version: 2\nemail_style:\n  header: hidden\n",
+			3, `unavailable email style: "hidden"`},
+		{"version", "version: 3\n",
+			1, "unsupported version 3; upgrade Gowizard"},
+		{"type", "version: 2\nimport:\n  key: value\n",
+			3, "cannot unmarshal"},
+		{"mapping", "- author\n",
+			1, "expected a mapping of keys"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := loadConfigFile("config.yaml", []byte(tt.data))
+		e, ok := err.(*ConfigError)
+		if !ok {
+			t.Errorf("%s: got error %v, want a ConfigError", tt.name, err)
+			continue
+		}
+		if e.File != "config.yaml" || e.Line != tt.line || !strings.Contains(e.Msg, tt.msg) {
+			t.Errorf("%s: got %q, want line %d with %q", tt.name, e, tt.line, tt.msg)
+		}
+	}
+}
+
+func TestLoadConfigFileVersion(t *testing.T) {
+	tests := []struct {
+		data     string
+		migrated bool
+		imports  []string
+	}{
+		{"import: github.com/jane\n", true, []string{"github.com/jane"}},
+		{"import: github.com/jane::example.com/jane\n", true,
+			[]string{"github.com/jane", "example.com/jane"}},
+		{"profiles:\n  work:\n    import: a.com:b.com\nimport: c.com\n", true,
+			[]string{"c.com"}},
+		{"version: 2\nimport:\n  - github.com/jane\n  - example.com/jane\n", false,
+			[]string{"github.com/jane", "example.com/jane"}},
+		{"", false, nil},
+	}
+
+	for i, tt := range tests {
+		cfg, migrated, err := loadConfigFile("config.yaml", []byte(tt.data))
+		if err != nil {
+			t.Errorf("#%d: %s", i, err)
+			continue
+		}
+		if migrated != tt.migrated {
+			t.Errorf("#%d: migrated: got %v, want %v", i, migrated, tt.migrated)
+		}
+		if cfg.Version != _CONFIG_VERSION {
+			t.Errorf("#%d: version: got %d", i, cfg.Version)
+		}
+		if strings.Join(cfg.Import, ",") != strings.Join(tt.imports, ",") {
+			t.Errorf("#%d: import: got %q, want %q", i, cfg.Import, tt.imports)
+		}
+	}
+
+	// The profiles are also converted.
+	cfg, _, err := loadConfigFile("config.yaml", []byte(tests[2].data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Profiles["work"].Import; len(got) != 2 || got[1] != "b.com" {
+		t.Errorf("import of profile: got %q", got)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kinds of version bump.
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+// changelogCategories are the subsections of a version in the changelog, in
+// order.
+var changelogCategories = []string{"Added", "Changed", "Deprecated", "Removed",
+	"Fixed", "Security"}
+
+// Header of a commit message in the format of Conventional Commits, like
+// "feat(parser)!: add arrays".
+var reConventional = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// commitTypes are the types of commits which are added to the changelog, and
+// their category.
+var commitTypes = map[string]string{
+	"feat": "Added",
+	"fix":  "Fixed",
+	"perf": "Changed",
+}
+
+// ChangelogEntry is a change got from a commit.
+type ChangelogEntry struct {
+	Category string // subsection of the changelog, like "Added"
+	Text     string
+	Breaking bool
+}
+
+// ChangelogResult is the result of generating the changelog.
+type ChangelogResult struct {
+	Since   string // last tag; empty if there is not one
+	Entries []ChangelogEntry
+	Bump    string // kind of bump for the next version
+	Next    string // next version
+}
+
+// GenerateChangelog reads the commits of the Git repository in "dir" since
+// the last tag of version, skipping the tags which are not a semantic version,
+// and adds the ones with the format of Conventional Commits to the section
+// "Unreleased" of the changelog, skipping the entries already in it. If write
+// is false, the changelog is not modified.
+//
+// The next version is suggested from the changes: major for breaking changes,
+// minor for features, and patch for fixes and improvements of performance.
+// Before of the version 1.0.0, the breaking changes increment the minor one.
+func GenerateChangelog(dir string, write bool) (*ChangelogResult, error) {
+	res := new(ChangelogResult)
+
+	var last Version
+	res.Since, last = lastVersionTag(dir)
+
+	args := []string{"log", "--no-merges", "--reverse", "--format=%h%x00%B%x1e"}
+	if res.Since != "" {
+		args = append(args, res.Since+"..HEAD")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) != 0 {
+			return nil, fmt.Errorf("git log: %s", bytes.TrimSpace(e.Stderr))
+		}
+		return nil, fmt.Errorf("git log: %s", err)
+	}
+
+	res.Bump = BumpNone
+	for _, commit := range strings.Split(string(out), "\x1e") {
+		hash, msg, found := strings.Cut(strings.TrimSpace(commit), "\x00")
+		if !found {
+			continue
+		}
+		entry, ok := parseConventional(hash, msg)
+		if !ok {
+			continue
+		}
+		res.Entries = append(res.Entries, entry)
+
+		switch {
+		case entry.Breaking:
+			res.Bump = BumpMajor
+		case entry.Category == "Added" && res.Bump != BumpMajor:
+			res.Bump = BumpMinor
+		case res.Bump == BumpNone:
+			res.Bump = BumpPatch
+		}
+	}
+
+	if res.Bump != BumpNone {
+		res.Next = last.Bump(res.Bump).String()
+	}
+
+	if write && len(res.Entries) != 0 {
+		if err = addChangelogEntries(filepath.Join(dir, _CHANGELOG), res.Entries); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// lastVersionTag returns the version of the last tag reachable from the
+// current commit in the Git repository in "dir". The tags which are not a
+// semantic version are skipped. Returns an empty tag if there is none.
+func lastVersionTag(dir string) (tag string, v Version) {
+	args := []string{"describe", "--tags", "--abbrev=0", "--match", "v[0-9]*"}
+
+	for {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return "", Version{}
+		}
+
+		tag = string(bytes.TrimSpace(out))
+		if v, err = ParseVersion(tag); err == nil {
+			return tag, v
+		}
+		args = append(args, "--exclude", tag)
+	}
+}
+
+// parseConventional parses the message of a commit. Returns false if it has
+// not the format of Conventional Commits, or its type is not in the
+// changelog.
+func parseConventional(hash, msg string) (ChangelogEntry, bool) {
+	header, body, _ := strings.Cut(msg, "\n")
+
+	m := reConventional.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return ChangelogEntry{}, false
+	}
+	typ, scope, subject := strings.ToLower(m[1]), m[2], m[4]
+
+	breaking := m[3] == "!"
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			breaking = true
+		}
+	}
+
+	category, ok := commitTypes[typ]
+	if !ok {
+		if !breaking {
+			return ChangelogEntry{}, false
+		}
+		category = "Changed"
+	}
+
+	text := subject
+	if scope != "" {
+		text = fmt.Sprintf("**%s:** %s", scope, subject)
+	}
+	if breaking {
+		text = "**Breaking:** " + text
+	}
+	return ChangelogEntry{category, fmt.Sprintf("- %s (%s)", text, hash), breaking}, true
+}
+
+// Bump returns the next version for the kind of bump. Before of the version
+// 1.0.0, a major bump increments the minor version.
+func (v Version) Bump(kind string) Version {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	if kind == BumpMajor && v.Major == 0 {
+		kind = BumpMinor
+	}
+	switch kind {
+	case BumpMajor:
+		next = Version{Major: v.Major + 1}
+	case BumpMinor:
+		next = Version{Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		// A pre-release is released as the same version.
+		if v.Pre == "" {
+			next.Patch++
+		}
+	}
+	return next
+}
+
+// addChangelogEntries adds the entries to the subsections of the section
+// "Unreleased" of the changelog "file". The entries already in the file are
+// skipped.
+func addChangelogEntries(file string, entries []ChangelogEntry) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	start, end, err := findUnreleased(lines)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for _, line := range lines {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	// == Subsections of the section
+
+	var preamble []string
+	titles := make([]string, 0)
+	sub := make(map[string][]string)
+	current := ""
+
+	for _, line := range lines[start+1 : end] {
+		if strings.HasPrefix(line, "### ") {
+			current = strings.TrimSpace(line[4:])
+			titles = append(titles, current)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if current == "" {
+			preamble = append(preamble, line)
+		} else {
+			sub[current] = append(sub[current], line)
+		}
+	}
+
+	added := false
+	for _, e := range entries {
+		if existing[e.Text] {
+			continue
+		}
+		existing[e.Text] = true
+		added = true
+
+		sub[e.Category] = append(sub[e.Category], e.Text)
+	}
+	if !added {
+		return nil
+	}
+	for _, v := range changelogCategories {
+		if len(sub[v]) != 0 && !containsString(titles, v) {
+			titles = append(titles, v)
+		}
+	}
+
+	// == Write
+
+	section := []string{""}
+	if len(preamble) != 0 {
+		section = append(section, preamble...)
+		section = append(section, "")
+	}
+	for _, title := range titles {
+		section = append(section, "### "+title, "")
+		if len(sub[title]) != 0 {
+			section = append(section, sub[title]...)
+			section = append(section, "")
+		}
+	}
+
+	out := make([]string, 0, len(lines)+len(entries))
+	out = append(out, lines[:start+1]...)
+	out = append(out, section...) // ended in a blank line
+	out = append(out, lines[end:]...)
+
+	return writeFile(file, []byte(strings.Join(out, "\n")))
+}
+
+// containsString reports whether the list has the string s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
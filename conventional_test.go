@@ -0,0 +1,131 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		msg   string
+		ok    bool
+		entry ChangelogEntry
+	}{
+		{"feat: add flag", true, ChangelogEntry{"Added", "- add flag (abc)", false}},
+		{"fix(parser): crash\n\nBody.", true, ChangelogEntry{"Fixed", "- **parser:** crash (abc)", false}},
+		{"perf: faster", true, ChangelogEntry{"Changed", "- faster (abc)", false}},
+		{"feat!: drop flag", true, ChangelogEntry{"Added", "- **Breaking:** drop flag (abc)", true}},
+		{"refactor: move API\n\nBREAKING CHANGE: the API is moved.", true,
+			ChangelogEntry{"Changed", "- **Breaking:** move API (abc)", true}},
+		{"chore: update deps", false, ChangelogEntry{}},
+		{"Update readme", false, ChangelogEntry{}},
+		{"feat:no space", false, ChangelogEntry{}},
+	}
+
+	for _, tt := range tests {
+		entry, ok := parseConventional("abc", tt.msg)
+		if ok != tt.ok || entry != tt.entry {
+			t.Errorf("%q: got %+v, %v; want %+v, %v", tt.msg, entry, ok, tt.entry, tt.ok)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		version, kind, want string
+	}{
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"1.2.3", BumpMinor, "1.3.0"},
+		{"1.2.3", BumpMajor, "2.0.0"},
+		{"0.2.3", BumpMajor, "0.3.0"},
+		{"1.0.0-rc.1", BumpPatch, "1.0.0"},
+		{"1.2.3+build", BumpNone, "1.2.3"},
+	}
+	for _, tt := range tests {
+		v, _ := ParseVersion(tt.version)
+		if got := v.Bump(tt.kind).String(); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.version, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	setGitIdentity(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, _CHANGELOG)
+
+	commit := func(msg string) string {
+		git(t, dir, "commit", "-q", "--allow-empty", "-m", msg)
+		return git(t, dir, "rev-parse", "--short", "HEAD")
+	}
+
+	git(t, dir, "init", "-q")
+	writeTestFile(t, file, "# Changelog\n\n## [Unreleased]\n\n### Added\n\n### Fixed\n")
+	git(t, dir, "add", ".")
+	commit("feat: first version")
+	git(t, dir, "tag", "-a", "v0.1.0", "-m", "Release v0.1.0")
+
+	feat := commit("feat(cli): add flag")
+	commit("Update readme")
+	commit("chore: update deps")
+	fix := commit("fix: crash on empty input\n\nCloses #1.")
+	// A tag of version which is not semantic is skipped.
+	git(t, dir, "tag", "v2-beta")
+
+	res, err := GenerateChangelog(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Since != "v0.1.0" || res.Bump != BumpMinor || res.Next != "0.2.0" {
+		t.Errorf("got since %q, bump %q, next %q", res.Since, res.Bump, res.Next)
+	}
+	if len(res.Entries) != 2 {
+		t.Fatalf("got entries %+v", res.Entries)
+	}
+
+	want := "# Changelog\n\n## [Unreleased]\n\n" +
+		"### Added\n\n- **cli:** add flag (" + feat + ")\n\n" +
+		"### Fixed\n\n- crash on empty input (" + fix + ")\n"
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// The entries are not added again, and the breaking changes are marked.
+	breaking := commit("refactor!: rename the API")
+	if res, err = GenerateChangelog(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if res.Bump != BumpMajor || res.Next != "0.2.0" {
+		t.Errorf("got bump %q, next %q", res.Bump, res.Next)
+	}
+	if data, err = os.ReadFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "add flag"); n != 1 {
+		t.Errorf("entry added %d times", n)
+	}
+	if !strings.Contains(string(data), "### Changed\n\n- **Breaking:** rename the API ("+breaking+")\n") {
+		t.Errorf("breaking change not added:\n%s", data)
+	}
+
+	// Without tags of version, all commits are read.
+	git(t, dir, "tag", "-d", "v0.1.0")
+	if res, err = GenerateChangelog(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if res.Since != "" || len(res.Entries) != 4 || res.Next != "0.1.0" {
+		t.Errorf("got since %q, %d entries, next %q", res.Since, len(res.Entries), res.Next)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// The years of a copyright line: a year, or a range of years.
+	reYears = regexp.MustCompile(`\b((?:19|20)\d\d)(?:\s*[-–]\s*((?:19|20)\d\d))?\b`)
+
+	// The copyright line of a license header, with the comment before of it.
+	reCopyrightLine = regexp.MustCompile(`^(\W*?)(Copyright\s|Written in\s|SPDX-FileCopyrightText:)`)
+)
+
+// Years returns the years of the copyright: the year of creation, or the range
+// until the year of the last change.
+func (c *Conf) Years() string {
+	if c.LastYear > c.Year {
+		return fmt.Sprintf("%d-%d", c.Year, c.LastYear)
+	}
+	return strconv.Itoa(c.Year)
+}
+
+// copyrightYears returns the years of the first copyright line of the text.
+// The last year is 0 when there is not a range.
+func copyrightYears(text string) (first, last int, found bool) {
+	for _, line := range strings.Split(text, "\n") {
+		if !reCopyrightLine.MatchString(line) {
+			continue
+		}
+		m := reYears.FindStringSubmatch(line)
+		if m == nil {
+			return 0, 0, false
+		}
+		first, _ = strconv.Atoi(m[1])
+		last, _ = strconv.Atoi(m[2])
+		return first, last, true
+	}
+	return 0, 0, false
+}
+
+// fileYears are the years of the first and the last change of a file.
+type fileYears struct {
+	first, last int
+}
+
+// gitYears returns the years of the changes of the files in the Git history of
+// the directory "dir", by path relative to it.
+func gitYears(dir string) (map[string]fileYears, error) {
+	if DetectVCS(dir) != "git" {
+		return nil, fmt.Errorf("%s: the copyright years from the VCS history require Git", dir)
+	}
+
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "log",
+		"--format=%x00%ad", "--date=format:%Y", "--name-only", "--relative")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) != 0 {
+			return nil, fmt.Errorf("git log: %s", bytes.TrimSpace(e.Stderr))
+		}
+		return nil, fmt.Errorf("git log: %s", err)
+	}
+
+	years := make(map[string]fileYears)
+	year := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			year, _ = strconv.Atoi(line[1:])
+			continue
+		}
+		if line == "" || year == 0 {
+			continue
+		}
+
+		y := years[line]
+		if y.first == 0 || year < y.first {
+			y.first = year
+		}
+		if year > y.last {
+			y.last = year
+		}
+		years[line] = y
+	}
+	return years, nil
+}
+
+// copyrightPolicy returns the policy of the copyright years, checking it.
+// By default, it is the one of the configuration, or "fixed".
+func (c *Conf) copyrightPolicy(policy string) (string, error) {
+	if policy == "" {
+		policy = c.CopyrightYears
+	}
+	if policy == "" {
+		return "fixed", nil
+	}
+	policy = strings.ToLower(policy)
+
+	if _, ok := ListCopyrightYears[policy]; !ok {
+		return "", fmt.Errorf("unavailable policy of copyright years: %q; valid: %s",
+			policy, strings.Join(sortedKeys(ListCopyrightYears), ", "))
+	}
+	return policy, nil
+}
+
+// setYears sets the years of the copyright of the file "name" from its
+// history, if any, keeping the years got from its header.
+func (c *Conf) setYears(history map[string]fileYears, name string, first, last int) {
+	if y, ok := history[name]; ok {
+		if first == 0 || y.first < first {
+			first = y.first
+		}
+		if y.last > last {
+			last = y.last
+		}
+	}
+	if first != 0 {
+		c.Year, c.LastYear = first, last
+	}
+}
+
+// UpdateHeaders updates the copyright line of the license headers rendered by
+// the templates, in the source files of the project in "dir", keeping the rest
+// of the header. The holder is rendered from the configuration of the project,
+// so it changes when the project moves to an organization.
+//
+// The years follow the policy "years"; by default, the one of the project:
+//
+//   - fixed: the years of the header are kept
+//   - vcs: the range of years from the first to the last commit of every file,
+//     from the Git history
+//
+// If check is true, the files are not changed.
+func UpdateHeaders(dir, years string, check bool) ([]HeaderResult, error) {
+	cfg, err := projectConf(dir)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := cfg.copyrightPolicy(years)
+	if err != nil {
+		return nil, err
+	}
+	var history map[string]fileYears
+	if policy == "vcs" {
+		if history, err = gitYears(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := NewProject(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.parseLicense()
+	matcher, err := newHeaderMatcher(p)
+	if err != nil {
+		return nil, err
+	}
+
+	year := cfg.Year
+	defer func() { cfg.Year, cfg.LastYear = year, 0 }()
+
+	results := make([]HeaderResult, 0)
+
+	err = walkSources(dir, cfg.attributes().generated, func(name, rel string, data []byte, style CommentStyle) error {
+		lines, start, end := findHeader(data, style)
+		block := strings.Join(lines[start:end], "")
+
+		res := HeaderResult{rel, HeaderFound}
+		switch {
+		case !reHeaderMark.MatchString(block):
+			res.Status = HeaderMissing
+		case !matcher.match(block):
+			res.Status = HeaderOther
+		}
+		if res.Status != HeaderFound {
+			results = append(results, res)
+			return nil
+		}
+
+		for i := start; i < end; i++ {
+			m := reCopyrightLine.FindStringSubmatch(lines[i])
+			if m == nil {
+				continue
+			}
+
+			cfg.Year, cfg.LastYear = year, 0
+			first, last, _ := copyrightYears(lines[i])
+			if policy == "vcs" {
+				cfg.setYears(history, rel, first, last)
+			} else if first != 0 {
+				cfg.Year, cfg.LastYear = first, last
+			}
+
+			var text []byte
+			if m[2] == "SPDX-FileCopyrightText:" {
+				text, err = p.renderVar("SPDXCopyright")
+				text = append([]byte("SPDX-FileCopyrightText: "), text...)
+			} else {
+				text, err = p.renderVar("Copyright")
+			}
+			if err != nil {
+				return err
+			}
+
+			line := m[1] + string(text) + "\n"
+			if line == lines[i] {
+				break
+			}
+			lines[i] = line
+
+			if res.Status = HeaderOutdated; !check {
+				if err = writeFile(name, []byte(strings.Join(lines, ""))); err != nil {
+					return err
+				}
+				res.Status = HeaderUpdated
+			}
+			break
+		}
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyrightYears(t *testing.T) {
+	tests := []struct {
+		text        string
+		first, last int
+		found       bool
+	}{
+		{"// Copyright 2020 Jane Doe\n", 2020, 0, true},
+		{"# Copyright 2019-2021 Jane Doe\n", 2019, 2021, true},
+		{"# Copyright 2019 - 2021 Jane Doe\n", 2019, 2021, true},
+		{"// Copyright (c) 2019–2021 Jane Doe\n", 2019, 2021, true},
+		{"// SPDX-FileCopyrightText: 2018 Jane Doe\n", 2018, 0, true},
+		{"// Written in 2017 by Jane Doe\n", 2017, 0, true},
+		{"// Package foo.\n//\n// Copyright 2016 Jane Doe\n", 2016, 0, true},
+		{"// Copyright Jane Doe\n", 0, 0, false},
+		{"// Released in 2020\n", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		first, last, found := copyrightYears(tt.text)
+		if first != tt.first || last != tt.last || found != tt.found {
+			t.Errorf("%q: got (%d, %d, %v), want (%d, %d, %v)",
+				tt.text, first, last, found, tt.first, tt.last, tt.found)
+		}
+	}
+}
+
+func TestSetYears(t *testing.T) {
+	history := map[string]fileYears{
+		"a.go": {2019, 2021},
+		"b.go": {2022, 2022},
+	}
+
+	tests := []struct {
+		name        string
+		first, last int // from the header
+		year, years string
+	}{
+		{"a.go", 0, 0, "", "2019-2021"},
+		{"a.go", 2020, 0, "", "2019-2021"},
+		{"a.go", 2018, 2020, "", "2018-2021"},
+		{"a.go", 2015, 2023, "", "2015-2023"},
+		{"b.go", 0, 0, "", "2022"},
+		{"c.go", 0, 0, "", "2024"}, // without history nor header
+		{"c.go", 2017, 2018, "", "2017-2018"},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{Year: 2024}
+		c.setYears(history, tt.name, tt.first, tt.last)
+		if got := c.Years(); got != tt.years {
+			t.Errorf("%s (%d, %d): got %q, want %q", tt.name, tt.first, tt.last, got, tt.years)
+		}
+	}
+}
+
+func TestCopyrightPolicy(t *testing.T) {
+	tests := []struct {
+		conf, flag string
+		policy     string // empty if error
+	}{
+		{"", "", "fixed"},
+		{"vcs", "", "vcs"},
+		{"vcs", "fixed", "fixed"},
+		{"", "VCS", "vcs"},
+		{"", "git", ""},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{CopyrightYears: tt.conf}
+		policy, err := c.copyrightPolicy(tt.flag)
+		if (err != nil) != (tt.policy == "") || policy != tt.policy {
+			t.Errorf("(%q, %q): got %q, %v; want %q", tt.conf, tt.flag, policy, err, tt.policy)
+		}
+	}
+}
+
+func TestUpdateHeaders(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{Year: 2020})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	// A script with a range of years, after of the shebang.
+	script := filepath.Join(dir, "run.sh")
+	writeTestFile(t, script, "#!/bin/sh\n\necho hi\n")
+	if _, err := Headers(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	year := strconv.Itoa(time.Now().Year())
+	writeTestFile(t, script, strings.Replace(string(data), "Copyright "+year, "Copyright 2019-2021", 1))
+
+	writeTestFile(t, filepath.Join(dir, "other.sh"),
+		"# Copyright 2018 Bob Smith\n#\n# Do what you want with this file, but do not blame me.\n\necho hi\n")
+	writeTestFile(t, filepath.Join(dir, "none.sh"), "echo hi\n")
+
+	// The project moves to another holder.
+	m, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Conf.Author = "John Roe"
+	if err = m.write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	status := func(results []HeaderResult, updated HeaderStatus) {
+		t.Helper()
+		for _, v := range results {
+			want := updated
+			switch v.File {
+			case "other.sh":
+				want = HeaderOther
+			case "none.sh":
+				want = HeaderMissing
+			}
+			if v.Status != want {
+				t.Errorf("%s: got status %q, want %q", v.File, v.Status, want)
+			}
+		}
+	}
+
+	results, err := UpdateHeaders(dir, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status(results, HeaderOutdated)
+	if data, _ = os.ReadFile(script); !strings.Contains(string(data), "Jane Doe") {
+		t.Errorf("run.sh: changed in check mode:\n%s", data)
+	}
+
+	if results, err = UpdateHeaders(dir, "fixed", false); err != nil {
+		t.Fatal(err)
+	}
+	status(results, HeaderUpdated)
+
+	tests := []struct {
+		name, line string
+	}{
+		{"run.sh", "#!/bin/sh\n\n# Copyright 2019-2021 John Roe\n"},
+		{p.cfg.Program + ".go", "// Copyright 2020 John Roe\n"},
+		{"other.sh", "# Copyright 2018 Bob Smith\n"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(dir, tt.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), tt.line) {
+			t.Errorf("%s: got\n%s\nwant the start %q", tt.name, data, tt.line)
+		}
+	}
+
+	// Nothing changes the next time.
+	if results, err = UpdateHeaders(dir, "", false); err != nil {
+		t.Fatal(err)
+	}
+	status(results, HeaderFound)
+}
+
+func TestUpdateHeadersVCS(t *testing.T) {
+	setDataDir(t)
+	setGitIdentity(t)
+
+	p := newTestProject(t, &Conf{VCS: "git", Commit: true, Year: 2020})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	// A file changed in 2019 and 2021.
+	script := filepath.Join(dir, "run.sh")
+	for _, year := range []string{"2019", "2021"} {
+		writeTestFile(t, script, "#!/bin/sh\n\n# Copyright 2020 Jane Doe\n#\n# SPDX-License-Identifier: MPL-2.0\n\necho "+year+"\n")
+		git(t, dir, "add", "run.sh")
+		t.Setenv("GIT_AUTHOR_DATE", year+"-06-01T12:00:00")
+		git(t, dir, "commit", "-q", "-m", "Change in "+year)
+	}
+
+	if _, err := UpdateHeaders(dir, "vcs", false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#!/bin/sh\n\n# Copyright 2019-2021 Jane Doe\n"; !strings.HasPrefix(string(data), want) {
+		t.Errorf("run.sh: got\n%s\nwant the start %q", data, want)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Module is a module required by the project.
+type Module struct {
+	Path    string
+	Version string
+}
+
+func (m Module) String() string { return m.Path + " " + m.Version }
+
+// requiredModules returns the modules required by the project in "dir", got
+// from its files "go.mod" and "go.sum", and the ones of the modules of the
+// workspace, sorted by path.
+// The modules downloaded according to "go.sum", but not listed in "go.mod"
+// (before of Go 1.17), are added with their highest version.
+func requiredModules(dir string, modules []string) ([]Module, error) {
+	modDirs := []string{"."}
+	modDirs = append(modDirs, modules...)
+
+	seen := make(map[string]bool)
+	deps := make([]Module, 0)
+	for _, v := range modDirs {
+		list, err := readRequire(filepath.Join(dir, v, "go.mod"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		sum, err := readSum(filepath.Join(dir, v, "go.sum"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		required := make(map[string]bool)
+		for _, mod := range list {
+			required[mod.Path] = true
+		}
+		for _, mod := range sum {
+			if !required[mod.Path] {
+				list = append(list, mod)
+			}
+		}
+
+		for _, mod := range list {
+			if !seen[mod.String()] {
+				seen[mod.String()] = true
+				deps = append(deps, mod)
+			}
+		}
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Path != deps[j].Path {
+			return deps[i].Path < deps[j].Path
+		}
+		return deps[i].Version < deps[j].Version
+	})
+	return deps, nil
+}
+
+// readRequire returns the modules required in the file "go.mod".
+func readRequire(file string) ([]Module, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := make([]Module, 0)
+	inBlock := false
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock:
+			if fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+		case fields[0] == "require":
+			if len(fields) == 2 && fields[1] == "(" {
+				inBlock = true
+				continue
+			}
+			fields = fields[1:]
+		default:
+			continue
+		}
+
+		if len(fields) == 2 {
+			list = append(list, Module{strings.Trim(fields[0], `"`), fields[1]})
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	return list, nil
+}
+
+// moduleCache returns the directory of the module cache.
+func moduleCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+}
+
+// escapeModule escapes a module path or version as in the module cache, where
+// every upper case letter is replaced by "!" and the letter in lower case.
+func escapeModule(s string) string {
+	var buf strings.Builder
+
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			buf.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// readSum returns the modules downloaded according to the file "go.sum", with
+// their highest version.
+func readSum(file string) ([]Module, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	highest := make(map[string]Version)
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		// The lines of the files "go.mod" have the version ended in "/go.mod".
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		v, err := ParseVersion(fields[1])
+		if err != nil {
+			continue
+		}
+		if _, ok := versions[fields[0]]; !ok || v.Compare(highest[fields[0]]) > 0 {
+			highest[fields[0]] = v
+			versions[fields[0]] = fields[1]
+		}
+	}
+
+	list := make([]Module, 0, len(versions))
+	for path, version := range versions {
+		list = append(list, Module{path, version})
+	}
+	return list, nil
+}
+
+// moduleDir returns the directory of the module in the module cache.
+func moduleDir(mod Module) string {
+	return filepath.Join(moduleCache(), escapeModule(mod.Path)+"@"+escapeModule(mod.Version))
+}
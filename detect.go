@@ -0,0 +1,270 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// LicenseMatch is a license found in a file.
+type LicenseMatch struct {
+	File    string  // path relative to the project directory
+	License string  // name in lower case, like "mpl" or "mit"
+	Score   float64 // similarity with the text of the license
+}
+
+// LicenseDetection is the license detected in a project.
+type LicenseDetection struct {
+	Matches []LicenseMatch
+
+	// License of the project, in lower case; several ones joined by "or" if it
+	// is dual. It is empty if no license has been found, or if the licenses
+	// found are not available to render the templates.
+	License string
+	Score   float64 // lowest score of the licenses of the project
+	Reuse   bool    // the license is given by identifiers of SPDX
+}
+
+// DetectLicense detects the license of the project in "dir", which could not
+// be created by Gowizard.
+//
+// The license files at the root, and the ones of the directory "LICENSES",
+// are compared with the texts of the data directory, skipping the copyright
+// lines and the differences of spaces. When there is no license file, the
+// license headers of the source files are used, choosing the license found in
+// more files.
+func DetectLicense(dir string) (*LicenseDetection, error) {
+	p, err := NewProject(&Conf{})
+	if err != nil {
+		return nil, err
+	}
+	corpus, err := loadLicenseCorpus(p.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	det := new(LicenseDetection)
+
+	// == License files
+
+	files, err := licenseFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range files {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		if name, score := corpus.classify(string(data)); name != "" {
+			rel, _ := filepath.Rel(dir, v)
+			det.Matches = append(det.Matches, LicenseMatch{
+				filepath.ToSlash(rel), strings.ToLower(name), score,
+			})
+		}
+	}
+
+	// The name of the file is the identifier of SPDX.
+	files, err = filepath.Glob(filepath.Join(dir, _REUSE_LICENSES, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	for _, v := range files {
+		if name, ok := licenseBySPDX(strings.TrimSuffix(filepath.Base(v), ".txt")); ok {
+			det.Matches = append(det.Matches, LicenseMatch{
+				path.Join(_REUSE_LICENSES, filepath.Base(v)), name, 1,
+			})
+			det.Reuse = true
+		}
+	}
+
+	if len(det.Matches) != 0 {
+		licenses := make([]string, 0)
+		det.Score = 1
+		for _, v := range det.Matches {
+			if !containsString(licenses, v.License) {
+				licenses = append(licenses, v.License)
+			}
+			if v.Score < det.Score {
+				det.Score = v.Score
+			}
+		}
+		det.setLicense(licenses)
+		return det, nil
+	}
+
+	// == License headers
+
+	if err = det.detectHeaders(dir, p); err != nil {
+		return nil, err
+	}
+	return det, nil
+}
+
+// detectHeaders detects the license from the headers of the source files.
+func (det *LicenseDetection) detectHeaders(dir string, p *project) error {
+	corpus, err := headerCorpus(p)
+	if err != nil {
+		return err
+	}
+	files, err := reuseFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	votes := make(map[string]int)
+	scores := make(map[string]float64)
+	spdx := make(map[string]bool)
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if _, ok := CommentStyleFor(name, data); !ok || reGenerated.Match(data) {
+			continue
+		}
+
+		lines := bytes.SplitN(data, []byte("\n"), _HEADER_LINES+1)
+		if len(lines) > _HEADER_LINES {
+			lines = lines[:_HEADER_LINES]
+		}
+		header := bytes.Join(lines, []byte("\n"))
+
+		var license string
+		var score float64
+
+		if m := reSPDXLicense.FindSubmatch(header); m != nil {
+			licenses := make([]string, 0)
+			for _, id := range spdxIdentifiers(trimCommentEnd(string(m[1]))) {
+				if name, ok := licenseBySPDX(id); ok {
+					licenses = append(licenses, name)
+				} else {
+					licenses = append(licenses, strings.ToLower(id))
+				}
+			}
+			license, score = strings.Join(licenses, _LICENSE_OR), 1
+			spdx[license] = true
+		} else if !hasHeader(header) {
+			continue
+		} else if license, score = corpus.classify(string(header)); license == "" {
+			continue
+		}
+
+		det.Matches = append(det.Matches, LicenseMatch{name, license, score})
+		votes[license]++
+		if s, ok := scores[license]; !ok || score < s {
+			scores[license] = score
+		}
+	}
+
+	best := ""
+	for k, n := range votes {
+		if n > votes[best] || (n == votes[best] && k < best) {
+			best = k
+		}
+	}
+	if best != "" {
+		det.Score = scores[best]
+		det.Reuse = spdx[best]
+		det.setLicense(splitLicense(best))
+	}
+	return nil
+}
+
+// setLicense sets the license of the project when all licenses are available
+// to render the templates.
+func (det *LicenseDetection) setLicense(licenses []string) {
+	for _, v := range licenses {
+		if _, ok := ListLowerLicense[v]; !ok {
+			return
+		}
+	}
+	det.License = strings.Join(licenses, _LICENSE_OR)
+}
+
+// headerCorpus returns the license headers rendered by the templates, without
+// comments, to classify the headers of the source files.
+func headerCorpus(p *project) (licenseCorpus, error) {
+	corpus := make(licenseCorpus, 0, len(ListLowerLicense))
+
+	for _, v := range sortedKeys(ListLowerLicense) {
+		if v == "none" { // it has only the copyright line
+			continue
+		}
+		hp := &project{p.dataDir, new(template.Template), &Conf{License: v}}
+		hp.parseLicense()
+
+		data, err := hp.renderHeader(CommentStyle{})
+		if err != nil {
+			return nil, fmt.Errorf("header of %s: %s", v, err)
+		}
+		bigrams, size := wordBigrams(string(data))
+		corpus = append(corpus, licenseText{v, bigrams, size})
+	}
+	return corpus, nil
+}
+
+// licenseBySPDX returns the name in lower case of the license with the
+// identifier of SPDX "id".
+func licenseBySPDX(id string) (string, bool) {
+	for k, v := range licenseSPDX {
+		if strings.EqualFold(v, id) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// projectConf returns the configuration of the project in "dir": the one
+// recorded in its manifest or, for projects not created by Gowizard, the user
+// configuration with the license detected in the project.
+func projectConf(dir string) (*Conf, error) {
+	if _, err := os.Stat(filepath.Join(dir, _MANIFEST)); !os.IsNotExist(err) {
+		m, err := readManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+		return m.Conf, nil
+	}
+
+	det, err := DetectLicense(dir)
+	if err != nil {
+		return nil, err
+	}
+	if det.License == "" {
+		if len(det.Matches) != 0 {
+			return nil, fmt.Errorf("%s: license %q not available; valid: %s", dir,
+				det.Matches[0].License, strings.Join(sortedKeys(ListLowerLicense), ", "))
+		}
+		return nil, fmt.Errorf("%s: license not detected", dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conf{Project: filepath.Base(abs), License: det.License, Reuse: det.Reuse}
+	c.setOrigin("license", "detected in "+det.Matches[0].File)
+
+	if err = c.UserConfig(); err != nil {
+		return nil, err
+	}
+	if err = c.SetNames(); err != nil {
+		return nil, err
+	}
+	c.ProjectHeader = strings.Repeat(_HEADER_CHAR, len(c.Project))
+	c.setLicenseNames()
+	return c, nil
+}
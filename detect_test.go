@@ -0,0 +1,85 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectLicense(t *testing.T) {
+	testdata, err := filepath.Abs(filepath.Join("testdata", "detect"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setDataDir(t)
+
+	tests := []struct {
+		dir     string
+		license string
+		reuse   bool
+		matches []LicenseMatch // the scores lower than 1 are not compared
+	}{
+		// The text reflowed, and with a copyright line.
+		{"file", "apache", false, []LicenseMatch{{"LICENSE.txt", "apache", 0}}},
+		// Indented, but the license is not available to render the templates.
+		{"unknown", "", false, []LicenseMatch{{"COPYING", "mit", 1}}},
+		{"reuse", "mpl", true, []LicenseMatch{{"LICENSES/MPL-2.0.txt", "mpl", 1}}},
+		// The generated files are skipped.
+		{"headers", "mpl", false, []LicenseMatch{
+			{"a.go", "mpl", 0}, {"b.go", "mpl", 0}, {"c.go", "apache", 1},
+		}},
+		{"none", "", false, nil},
+	}
+
+	for _, tt := range tests {
+		det, err := DetectLicense(filepath.Join(testdata, tt.dir))
+		if err != nil {
+			t.Errorf("%s: %s", tt.dir, err)
+			continue
+		}
+		if det.License != tt.license || det.Reuse != tt.reuse {
+			t.Errorf("%s: got license %q (reuse %v), want %q (reuse %v)",
+				tt.dir, det.License, det.Reuse, tt.license, tt.reuse)
+		}
+
+		matches := make([]LicenseMatch, len(det.Matches))
+		for i, v := range det.Matches {
+			if v.Score < _LICENSE_MIN_SCORE || v.Score > 1 {
+				t.Errorf("%s: %s: score %.2f", tt.dir, v.File, v.Score)
+			}
+			if v.Score < 1 {
+				v.Score = 0
+			}
+			matches[i] = v
+		}
+		if len(matches) == 0 {
+			matches = nil
+		}
+		if !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("%s: got matches %v, want %v", tt.dir, matches, tt.matches)
+		}
+	}
+}
+
+func TestAddModuleWithoutManifest(t *testing.T) {
+	testdata, err := filepath.Abs(filepath.Join("testdata", "detect", "headers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setDataDir(t)
+
+	// The license is detected, but the modules are not added.
+	if _, err = projectConf(testdata); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AddModule(testdata, "api"); err == nil || !strings.Contains(err.Error(), "created by Gowizard") {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Styles to render the email in the output files.
+const (
+	EmailPlain      = "plain"      // user@host
+	EmailObfuscated = "obfuscated" // user AT host
+	EmailOmitted    = "omitted"    // not rendered
+)
+
+// Output files where the email is rendered, and the style used by default.
+var (
+	ListEmailOutputs = []string{"authors", "contributors", "header", "gomod"}
+
+	defaultEmailStyle = map[string]string{
+		"authors":      EmailObfuscated,
+		"contributors": EmailObfuscated,
+		"header":       EmailOmitted, // in the copyright line
+		"gomod":        EmailOmitted, // comment with the maintainer
+	}
+)
+
+// checkEmailStyle checks the style for the output file.
+func checkEmailStyle(output, style string) error {
+	if _, ok := defaultEmailStyle[output]; !ok {
+		return fmt.Errorf("unavailable output for email style: %q; valid: %s",
+			output, strings.Join(ListEmailOutputs, ", "))
+	}
+	switch style {
+	case EmailPlain, EmailObfuscated, EmailOmitted:
+		return nil
+	}
+	return fmt.Errorf("unavailable email style: %q; valid: %s, %s, %s",
+		style, EmailPlain, EmailObfuscated, EmailOmitted)
+}
+
+// EmailStyleFor returns the style used to render the email in the output file.
+func (c *Conf) EmailStyleFor(output string) string {
+	if v, ok := c.EmailStyle[output]; ok {
+		return v
+	}
+	return defaultEmailStyle[output]
+}
+
+// EmailFor returns the email of the author rendered for the output file, which
+// is empty if its style is to omit it.
+func (c *Conf) EmailFor(output string) string {
+	return c.renderEmail(output, c.Email)
+}
+
+// Address returns the author followed by the email rendered for the output
+// file, like "Name <user AT host>".
+func (c *Conf) Address(output string) string {
+	return c.address(output, c.Author, c.Email)
+}
+
+// renderEmail returns the email rendered for the output file.
+func (c *Conf) renderEmail(output, email string) string {
+	if email == "" {
+		return ""
+	}
+
+	switch c.EmailStyleFor(output) {
+	case EmailPlain:
+		return email
+	case EmailObfuscated:
+		return strings.Replace(email, "@", " AT ", -1)
+	}
+	return ""
+}
+
+// address returns the name followed by the email rendered for the output file.
+func (c *Conf) address(output, name, email string) string {
+	if email = c.renderEmail(output, email); email != "" {
+		if name == "" {
+			return "<" + email + ">"
+		}
+		return fmt.Sprintf("%s <%s>", name, email)
+	}
+	return name
+}
+
+// unobfuscateEmail returns the email from an address obfuscated like
+// "Name <user AT host>", as it was stored in the configuration files and in
+// the manifest until the email styles were added.
+func unobfuscateEmail(s string) string {
+	if i := strings.IndexByte(s, '<'); i != -1 && strings.HasSuffix(s, ">") {
+		s = s[i+1 : len(s)-1]
+	}
+	return strings.Replace(s, " AT ", "@", 1)
+}
@@ -0,0 +1,154 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// formatGo formats the Go source "src" rendered by the template "tmplName",
+// like gofmt and goimports do: the empty import declarations are removed, and
+// the imports are grouped: the standard library, the third-party packages, and
+// the local ones, which are under the module path "local".
+func formatGo(tmplName, local string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, sourceError(tmplName, src, err)
+	}
+	src = groupImports(fset, file, src, local)
+
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, sourceError(tmplName, src, err)
+	}
+	return out, nil
+}
+
+// sourceError returns an error with the line of the source "src" where is
+// the first error.
+func sourceError(tmplName string, src []byte, err error) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return fmt.Errorf("template %q: invalid Go source: %s", tmplName, err)
+	}
+	pos := list[0].Pos
+
+	line := ""
+	if lines := strings.Split(string(src), "\n"); pos.Line > 0 && pos.Line <= len(lines) {
+		line = strings.TrimSpace(lines[pos.Line-1])
+	}
+	return fmt.Errorf("template %q: invalid Go source at line %d: %s\n\t%d: %s",
+		tmplName, pos.Line, list[0].Msg, pos.Line, line)
+}
+
+// groupImports rewrites the import declarations of the source: the empty
+// ones are removed, and the specs are split in a group for the standard
+// library, other one for the third-party packages, and other one for the
+// packages under the module path "local". The declarations with comments are
+// not changed.
+func groupImports(fset *token.FileSet, file *ast.File, src []byte, local string) []byte {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := make([]edit, 0)
+
+	for _, d := range file.Decls {
+		decl, ok := d.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+		start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+
+		if len(decl.Specs) == 0 {
+			// Remove up to the end of the line.
+			for end < len(src) && src[end] == '\n' {
+				end++
+			}
+			edits = append(edits, edit{start, end, ""})
+			continue
+		}
+		if !decl.Lparen.IsValid() || hasComments(fset, file, start, end) {
+			continue
+		}
+
+		var std, other, own []string
+		for _, s := range decl.Specs {
+			spec := s.(*ast.ImportSpec)
+			line := spec.Path.Value
+			if spec.Name != nil {
+				line = spec.Name.Name + " " + line
+			}
+
+			switch importPath := strings.Trim(spec.Path.Value, "\"`"); {
+			case isLocalImport(importPath, local):
+				own = append(own, line)
+			case isStdImport(importPath):
+				std = append(std, line)
+			default:
+				other = append(other, line)
+			}
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("import (\n")
+		for _, group := range [][]string{std, other, own} {
+			if len(group) == 0 {
+				continue
+			}
+			if buf.Len() != len("import (\n") {
+				buf.WriteByte('\n')
+			}
+			sort.Strings(group)
+			for _, v := range group {
+				buf.WriteString("\t" + v + "\n")
+			}
+		}
+		buf.WriteString(")")
+
+		edits = append(edits, edit{start, end, buf.String()})
+	}
+
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+	}
+	return src
+}
+
+// hasComments reports whether there is some comment between the offsets
+// start and end.
+func hasComments(fset *token.FileSet, file *ast.File, start, end int) bool {
+	for _, c := range file.Comments {
+		if off := fset.Position(c.Pos()).Offset; off >= start && off < end {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdImport reports whether the import path is from the standard library,
+// whose first element has not a dot.
+func isStdImport(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// isLocalImport reports whether the import path is under the module path
+// "local".
+func isLocalImport(importPath, local string) bool {
+	return local != "" && (importPath == local || strings.HasPrefix(importPath, local+"/"))
+}
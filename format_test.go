@@ -0,0 +1,64 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGo(t *testing.T) {
+	const local = "example.com/foo"
+
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"empty import",
+			"package foo\n\nimport (\n\t\n)\n\n\nfunc F() {}\n",
+			"package foo\n\nfunc F() {}\n"},
+		{"only stdlib",
+			"package foo\n\nimport (\n\"strings\"\n\"fmt\"\n)\n",
+			"package foo\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n"},
+		{"stdlib and third-party",
+			"package foo\n\nimport (\n\"github.com/bar/baz\"\n\"os\"\n)\n",
+			"package foo\n\nimport (\n\t\"os\"\n\n\t\"github.com/bar/baz\"\n)\n"},
+		{"stdlib, third-party and local",
+			"package foo\n\nimport (\n\"example.com/foo/internal\"\n\"github.com/bar/baz\"\n\"os\"\n\"example.com/foo\"\n\"example.com/foobar\"\n)\n",
+			"package foo\n\nimport (\n\t\"os\"\n\n\t\"example.com/foobar\"\n\t\"github.com/bar/baz\"\n\n\t\"example.com/foo\"\n\t\"example.com/foo/internal\"\n)\n"},
+		{"stdlib and local",
+			"package foo\n\nimport (\nx \"example.com/foo/x\"\n\"io\"\n)\n",
+			"package foo\n\nimport (\n\t\"io\"\n\n\tx \"example.com/foo/x\"\n)\n"},
+		{"with comments, not changed",
+			"package foo\n\nimport (\n\"github.com/bar/baz\" // baz\n\"os\"\n)\n",
+			"package foo\n\nimport (\n\t\"github.com/bar/baz\" // baz\n\t\"os\"\n)\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := formatGo("Test", local, []byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out, tt.out)
+		}
+	}
+}
+
+func TestFormatGoError(t *testing.T) {
+	_, err := formatGo("Test", "", []byte("package foo\n\nfunc F() {\n\treturn 1 +\n}\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, v := range []string{`template "Test"`, "line 5"} {
+		if !strings.Contains(err.Error(), v) {
+			t.Errorf("error without %q: %s", v, err)
+		}
+	}
+}
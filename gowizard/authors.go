@@ -0,0 +1,37 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import "github.com/tredoe/wizard"
+
+var cmdAuthorsSync = &command{
+	name:  "authors sync",
+	args:  "[directory]",
+	short: "Rebuild the files of authors and contributors from the Git history",
+}
+
+func init() {
+	cmdAuthorsSync.run = runAuthorsSync
+}
+
+func runAuthorsSync(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	changes, err := wizard.SyncAuthors(dir)
+	if err != nil {
+		return err
+	}
+	printChanges(changes)
+	return nil
+}
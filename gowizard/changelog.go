@@ -0,0 +1,55 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdChangelog = &command{
+	name:  "changelog",
+	args:  "[-dir directory] [-n]",
+	short: "Add the Conventional Commits since the last tag to the changelog",
+}
+
+var (
+	fChangelogDir    = cmdChangelog.flag.String("dir", ".", "directory of the project")
+	fChangelogDryRun = cmdChangelog.flag.Bool("n", false, "print the entries, without changing the changelog")
+)
+
+func init() {
+	cmdChangelog.run = runChangelog
+}
+
+func runChangelog(cmd *command, args []string) error {
+	if len(args) != 0 {
+		cmd.usage()
+	}
+
+	res, err := wizard.GenerateChangelog(*fChangelogDir, !*fChangelogDryRun)
+	if err != nil {
+		return err
+	}
+
+	since := res.Since
+	if since == "" {
+		since = "the first commit"
+	}
+	if len(res.Entries) == 0 {
+		fmt.Printf("  no changes since %s\n", since)
+		return nil
+	}
+
+	fmt.Printf("  changes since %s:\n\n", since)
+	for _, e := range res.Entries {
+		fmt.Printf("  %-8s %s\n", e.Category, e.Text)
+	}
+	fmt.Printf("\n  next version: %s (%s)\n", res.Next, res.Bump)
+	return nil
+}
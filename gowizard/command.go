@@ -0,0 +1,79 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A command is an action run as "gowizard <name> [flags] [arguments]".
+type command struct {
+	name  string // name used in the command line; may have several words
+	args  string // arguments shown in the usage
+	short string // short description
+	flag  flag.FlagSet
+	run   func(cmd *command, args []string) error
+}
+
+// commands lists the available commands.
+var commands = []*command{
+	cmdUpgrade,
+	cmdAddModule,
+	cmdConfigShow,
+	cmdConfigMigrate,
+	cmdAuthorsSync,
+	cmdRelease,
+	cmdChangelog,
+	cmdHeader,
+	cmdNotice,
+	cmdLicensesAudit,
+	cmdLicensesDetect,
+	cmdReuseLint,
+	cmdRelicense,
+}
+
+// usage prints the usage of the command, and exits.
+func (c *command) usage() {
+	fmt.Fprintf(os.Stderr, "Usage: gowizard %s %s\n\n%s.\n\n", c.name, c.args, c.short)
+	c.flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// lookupCommand returns the command named by the first arguments, and the
+// rest of arguments. Returns nil if no command matches.
+func lookupCommand(args []string) (*command, []string) {
+	for _, c := range commands {
+		words := strings.Fields(c.name)
+		if len(args) < len(words) {
+			continue
+		}
+
+		found := true
+		for i, w := range words {
+			if args[i] != w {
+				found = false
+				break
+			}
+		}
+		if found {
+			return c, args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+// runCommand parses the flags of the command, and runs it.
+func runCommand(c *command, args []string) error {
+	c.flag.Init(c.name, flag.ExitOnError)
+	c.flag.Usage = c.usage
+	c.flag.Parse(args)
+
+	return c.run(c, c.flag.Args())
+}
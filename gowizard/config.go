@@ -0,0 +1,86 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdConfigShow = &command{
+	name:  "config show",
+	args:  "[-origin] [-profile name]",
+	short: "Show the configuration got from environment and files",
+}
+
+var cmdConfigMigrate = &command{
+	name:  "config migrate",
+	short: "Rewrite the user configuration in the current format",
+}
+
+var (
+	fConfigOrigin  = cmdConfigShow.flag.Bool("origin", false, "show where each value comes from")
+	fConfigProfile = cmdConfigShow.flag.String("profile", "", "profile of the user configuration")
+)
+
+func init() {
+	cmdConfigShow.run = runConfigShow
+	cmdConfigMigrate.run = runConfigMigrate
+}
+
+func runConfigShow(cmd *command, args []string) error {
+	if len(args) != 0 {
+		cmd.usage()
+	}
+
+	cfg := &wizard.Conf{Profile: *fConfigProfile}
+	if err := cfg.UserConfig(); err != nil {
+		return err
+	}
+
+	if cfg.Profile != "" {
+		printValue("profile", cfg.Profile, cfg.Origins["profile"])
+	}
+
+	for _, k := range wizard.ListConfigKeys {
+		value, origin := cfg.Get(k)
+		printValue(k, value, origin)
+	}
+	for _, k := range wizard.ListEmailOutputs {
+		printValue("email_style."+k, cfg.EmailStyleFor(k), cfg.Origins["email_style."+k])
+	}
+	return nil
+}
+
+func runConfigMigrate(cmd *command, args []string) error {
+	if len(args) != 0 {
+		cmd.usage()
+	}
+
+	files, err := wizard.MigrateConfig()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("The user configuration is already in the current format")
+	}
+	for _, v := range files {
+		fmt.Printf("Migrated: %s\n", v)
+	}
+	return nil
+}
+
+// printValue prints a value of the configuration, and its origin if it has
+// been set by the flag "origin".
+func printValue(key, value, origin string) {
+	if *fConfigOrigin && origin != "" {
+		fmt.Printf("%s: %s\t# %s\n", key, value, origin)
+	} else {
+		fmt.Printf("%s: %s\n", key, value)
+	}
+}
@@ -17,10 +17,110 @@ files got from compiling and linking.
 Configuration
 
 To don't repeat the same every time you create a project, you could use an user
-configuration file in your home directory to have values by default.
+configuration file to have values by default, which is created in
+"$XDG_CONFIG_HOME/gowizard/config.yaml" ("~/.config/gowizard/config.yaml" if
+that variable is not set):
 
 	gowizard -i -cfg
 
+The values are got from several layers; a value set in a layer is not changed
+by the next ones. From the highest precedence to the lowest:
+
+	flags
+	environment   GOWIZARD_ORG, GOWIZARD_AUTHOR, GOWIZARD_EMAIL,
+	              GOWIZARD_LICENSE, GOWIZARD_VCS, GOWIZARD_IMPORT,
+	              GOWIZARD_BRANCH, GOWIZARD_REMOTE, GOWIZARD_IGNORE,
+	              GOWIZARD_GENERATED, GOWIZARD_BINARY,
+	              GOWIZARD_COPYRIGHT_YEARS, GOWIZARD_README
+	project       ".gowizard.yaml" in the current directory or a parent one
+	user          "$XDG_CONFIG_HOME/gowizard/config.yaml", then "~/.gowizard"
+	system        "gowizard/config.yaml" in every directory of $XDG_CONFIG_DIRS
+	              ("/etc/xdg" by default)
+
+A configuration file can define profiles, to switch between several sets of
+values; i.e. for the company and for personal projects:
+
+	version: 2
+	author: Jane Doe
+	email: jane@home.example
+	license: mpl
+	profiles:
+	  work:
+	    org: Example Inc.
+	    email: jane@example.com
+	    license: apache
+	    match:
+	      - ~/src/example.com
+	      - example.com/
+
+In every file, the values of the profile used have precedence over the rest.
+A profile can have every key of the file but "version", "profiles", "authors"
+and "contributors", which are only allowed at the top level.
+The profile is set by the flag -profile, or by the variable GOWIZARD_PROFILE;
+else it is used the one whose prefixes in "match" have the longest one of the
+current directory or the import path. The profile "none" disables them. In
+interactive mode, the profile is asked at the start.
+
+The configuration files are checked when they are loaded: unknown keys, and
+invalid licenses, VCSs or emails are reported with their line and column. The
+files in the old format, without the key "version", with the import paths in a
+colon-separated list and the email obfuscated, are read converting them to the
+current one. The files of the user are only rewritten by the next command, or
+when the user configuration is created (flag -cfg); the old file is kept with
+the extension ".bak", and "~/.gowizard" is moved to the XDG location:
+
+	gowizard config migrate
+
+The email is kept as it is given, and it is rendered in every output file with
+a style: plain ("user@host"), obfuscated ("user AT host") or omitted. The
+outputs are: authors and contributors files (obfuscated by default), the
+copyright line of headers, and a comment in "go.mod" files (both omitted by
+default). They are set with the flag -email-style, or in the configuration:
+
+	email_style:
+	  authors: plain
+	  header: obfuscated
+
+The project can have several authors, the copyright holders, and contributors.
+Without them, the author of the configuration is used for both files:
+
+	authors:
+	  - name: Jane Doe
+	    email: jane@example.com
+	    org: Example Inc.
+	contributors:
+	  - name: John Roe
+	    email: john@example.com
+
+An author with an organization is listed by its name. The lists are sorted and
+without duplicates, which are found by the email or else by the name. The
+authors of the commits, and their co-authors given in the trailer
+"Co-authored-by", are added to them from the Git history, using ".mailmap":
+
+	gowizard authors sync [directory]
+
+Only the list of names is rewritten; the text before of it is kept as it is.
+
+To list the values got, and where each one comes from:
+
+	gowizard config show -origin
+
+The author, email and organization not found in that file are got from the
+configuration of the VCS, looking for them in the repository where Gowizard is
+run, if any, and then in the global files of the user:
+
+	git  ".git/config", "~/.gitconfig", "$XDG_CONFIG_HOME/git/config"
+	hg   ".hg/hgrc", "~/.hgrc", "$XDG_CONFIG_HOME/hg/hgrc"
+	jj   ".jj/repo/config.toml", "~/.jjconfig.toml",
+	     "$XDG_CONFIG_HOME/jj/config.toml"
+	bzr  ".bzr/branch/branch.conf", "$XDG_CONFIG_HOME/breezy/breezy.conf",
+	     "~/.bazaar/bazaar.conf"
+
+The organization is got from the keys "user.organization" of Git and Jujutsu,
+and "ui.organization" of Mercurial, set by the user, since the VCSs have not
+it. The files are read directly, without running the VCS tools. In interactive
+mode, every prompt shows the file where its default value comes from.
+
 Create project
 
 By default, the program name (flag *-program*) is named as the project name but
@@ -30,8 +130,233 @@ The flag -import is the import path of your project, but you must substitute the
 project name by "$" since Gowizard uses it to add the name automatically. For
 example: *github.com/tredoe/$*
 
+The flag -kind sets the layout of the project (list them with -lk):
+
+	library               package with its test and example files (by default)
+	command               main.go with flag parsing, and its test
+	library-with-command  the library, plus its command in "cmd/<program>/"
+	service               HTTP server with graceful shutdown in "cmd/<program>/",
+	                      and its handlers in "internal/server/"
+
+The kind service requires the import path. Every layout has its "go.mod", whose
+module path is the import path, or the program name when it is not set.
+
+The Go files are formatted like gofmt does, with the imports grouped in the
+standard library, the third-party packages, and the packages of the module.
+
+The readme file has the sections Installation, Usage, Contributing and License,
+whose content depends on the kind of project: "go get" for the libraries, and
+"go install" for the commands and services. When the import path is set, it
+gets the badges of pkg.go.dev and Go Report Card (not for proprietary
+projects), plus the ones of CI and coverage for the projects hosted in GitHub or
+GitLab, whose file of CI is created: ".github/workflows/ci.yml", which uploads
+the coverage to Codecov, or ".gitlab-ci.yml". In a workspace, the tests are run
+by module. It is written in Markdown by default; the flag -readme, or the key
+"readme" of the configuration, sets it to AsciiDoc ("README.adoc").
+
+Repository
+
+After of creating the files, the repository of the VCS is initialized. It can
+also be set the default branch (flag -branch), the remote repository (flag
+-remote), and the generated files can be committed (flag -commit), with the
+message of -commit-msg ("Initial commit" by default) and the author of
+-commit-author (the project author by default):
+
+	gowizard -name Foo -import github.com/org -commit -branch main \
+		-remote 'git@github.com:org/$.git'
+
+In the remote, "$" is replaced by the program name. The values "ssh" and
+"https" build it from the import path. The branch and remote can be set in the
+configuration files too.
+
+The VCSs are (list them with -lv): Git, Mercurial, Fossil, Subversion and
+Jujutsu. The ignore patterns are written in the file of every VCS, or in the
+property "svn:ignore" for Subversion. The repositories of Fossil and Subversion
+are created beside the project directory, as "<program>.fossil" and
+"<program>.svnrepo", and opened in it. Fossil does not support the branch, nor
+Subversion the branch and the remote.
+
+The ignore file is assembled from fragments, which are set by the flag -ignore
+or the key "ignore" of the configuration (list them with -li):
+
+	binary    program built by "go build"
+	coverage  coverage profiles and reports
+	editors   backup and swap files of editors
+	go        test binaries, profiles and shared libraries
+	ide       settings of IDEs
+	os        files generated by the operating systems
+	vendor    vendored dependencies
+
+All of them but ide and vendor are used by default; "none" does not ignore any
+file. The patterns are translated to the syntax of every VCS.
+
+The line endings are LF, in the repository and the working tree, by the file
+".gitattributes" with Git and Jujutsu, ".hgeol" with Mercurial (the extension
+"eol" is enabled in the repository), and the property "svn:auto-props" with
+Subversion; Fossil warns about CRLF at committing. The Go files use the Go diff
+driver. The patterns of generated files (flag -generated, "*_gen.go" by
+default) are marked to be hidden in diffs of Git hostings, and the ones of
+binary files (flag -binary, "testdata/**" by default) are not converted nor
+merged. Both can be set in the configuration, and "none" disables them.
+
+Workspace
+
+A repository with several modules is created using the flag -modules, with the
+directories of the modules:
+
+	gowizard -name Foo -import github.com/tredoe -modules api,client
+
+The repository root gets the file "go.work", and the files shared by all the
+modules: license, authors, readme. Every module gets its "go.mod", whose module
+path is the import path of the project followed by the directory, and the
+layout given by the flag -kind.
+
+For the kinds with a library, the base of the directory is the package name, so
+it has to be an identifier of Go; i.e. "client" but not "my-client".
+
+Modules added later are registered in "go.work", the readme and the file of CI,
+keeping the changes done by the user on them:
+
+	gowizard add module [-dir directory] name...
+
+Changelog
+
+The changes are documented in "CHANGELOG.md", following the format of "Keep a
+Changelog", under the section "Unreleased". To release a version, moving them
+to a section with the version and the date:
+
+	gowizard release [-dir directory] [-date YYYY-MM-DD] [-tag] version
+
+The version has to be a semantic version, higher than the released ones. The
+flag -tag commits the changelog, and tags it as "v<version>"; the tag must not
+exist, and the changelog has to be the only file with changes not committed.
+
+With Git, the entries can be got from the commits since the last tag of
+version, whose messages follow Conventional Commits: "feat" is added to
+"Added", "fix" to "Fixed", "perf" to "Changed", and the breaking changes ("!"
+after the type, or the footer "BREAKING CHANGE:") are marked. The entries
+already in the changelog are skipped, and the next version is suggested:
+
+	gowizard changelog [-dir directory] [-n]
+
+License header
+
+The license header is commented with the style of every kind of file, got from
+its extension, its name or its shebang: "//" for Go, Protobuf and JavaScript;
+"#" for shell scripts, YAML, Makefile and Dockerfile; "--" for SQL; blocks of C
+for C and CSS; "<!-- -->" for HTML and XML, included the HTML templates
+(".gohtml"); and the comments of the templates for the Go templates (".tmpl"
+and ".gotmpl"). To add the header to the source files of a project which have
+not it:
+
+	gowizard header [-dir directory] [-check]
+
+The flag -check only lists them, failing if there is any; i.e. to be used in
+the continuous integration. The hidden files, "vendor", "testdata" and the
+generated files are skipped. The headers added have the current year.
+
+The copyright line of the headers rendered by the templates is updated with:
+
+	gowizard header -update [-years policy] [-dir directory] [-check]
+
+The holder is rendered again, so it changes i.e. when the project moves to an
+organization (flag -org), and the rest of the header is kept. The years follow
+the policy set by the key "copyright_years" (flag -copyright-years), or by the
+flag -years:
+
+	fixed   the years of the header are kept; by default
+	vcs     the range of years from the first to the last commit of every file,
+	        like "2019-2026", got from the Git history
+
+With the policy vcs, the headers added to the files which have history get
+their range of years too.
+
+Notice
+
+With the Apache License, the file "NOTICE" is created with the project name
+and the copyright line. Since the notices of the dependencies have to be
+redistributed too, they can be added after of the own one, being got from the
+modules required in the files "go.mod", which have to be in the module cache:
+
+	gowizard notice [directory]
+
+The command fails on projects without the Apache License.
+
+REUSE
+
+The flag -reuse creates the project following the REUSE specification
+(https://reuse.software/): the licenses are in "LICENSES/<SPDX>.txt", the
+headers only have the tags "SPDX-FileCopyrightText" and
+"SPDX-License-Identifier", and the file "REUSE.toml" annotates the files which
+can not have a header, testdata and images. To check a tree without network
+access:
+
+	gowizard reuse lint [directory]
+
+Every file needs its copyright and license, in its header, in a file
+"<name>.license", or in "REUSE.toml" or ".reuse/dep5"; and the texts of the
+licenses used have to be in "LICENSES", without unused ones. With Git, the
+ignored files are skipped.
+
+License compatibility
+
+The licenses differ in the strength of their copyleft (none for Apache and
+CC0; by file for MPL; the whole program for GPL; and also its use over a
+network for AGPL), and in the grant of patents (all but CC0). A warning is
+printed when the license does not fit the project: AGPL for an organization,
+GPL for a library, or a dual license ("mpl or apache") whose options contradict
+each other. In interactive mode, the terms of the license chosen are explained.
+
+License audit
+
+The licenses of the dependencies are checked against the one of the project,
+without network access: the modules required in "go.mod" and "go.sum" are got
+from the module cache, and their license files are compared with the texts in
+the data directory (the licenses of projects, MIT, BSD and ISC). A dependency
+with strong copyleft can only be used by projects whose copyleft is as strong:
+GPL by projects under GPL or AGPL, and AGPL by projects under AGPL.
+
+	gowizard licenses audit [directory]
+
+The commands header, notice and licenses audit can be run on projects not
+created by Gowizard, but not "add module", which needs the manifest. Their
+license is detected from the license files, at the root and in the directory
+"LICENSES", or else from the license headers of the source files, comparing
+them with the texts in the data directory; the copyright lines and the spaces
+are skipped. The rest of values are got from the user configuration. To show
+the license detected, with the similarity of every file:
+
+	gowizard licenses detect [directory]
+
+Relicense
+
+The license of a project is changed with:
+
+	gowizard relicense -to mpl [-w] [directory]
+
+The license headers which match the ones of the templates are replaced by the
+new header, keeping their year; the headers of other licenses are kept. The
+files with the text of the license are replaced, as the section "License" of
+the readme file and the license expression of REUSE.toml. The changes are shown
+as a diff, and they are only applied with the flag -w.
+
+Interactive mode
+
 The way fastest and simple to create it, is using the interactive mode:
 
 	gowizard -i
+
+Upgrade project
+
+The configuration used to create a project and the files rendered at that time
+are recorded in the file ".gowizard-manifest.json", which should be committed;
+the texts of the licenses, copied from the data directory, only by their hash.
+When the templates are improved, the project can be updated with:
+
+	gowizard upgrade [directory]
+
+Every file is merged between the previous render, the new one and the current
+file, so the user changes are kept. The regions changed by both sides are
+written between conflict markers, which have to be resolved by hand.
 */
 package main
@@ -10,6 +10,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/tredoe/dat/question"
@@ -36,23 +37,91 @@ func (i *importPaths) Set(value string) error {
 	return nil
 }
 
-var fImportPath importPaths
+// commaList is a comma-separated list.
+type commaList []string
+
+func (m *commaList) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *commaList) Set(value string) error {
+	*m = make([]string, 0)
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*m = append(*m, v)
+		}
+	}
+	return nil
+}
+
+type emailStyle map[string]string
+
+func (e *emailStyle) String() string {
+	list := make([]string, 0, len(*e))
+	for k, v := range *e {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+func (e *emailStyle) Set(value string) error {
+	*e = make(map[string]string)
+
+	for _, v := range strings.Split(value, ",") {
+		output, style, found := strings.Cut(strings.TrimSpace(v), "=")
+		if !found {
+			return fmt.Errorf("expected output=style: %q", v)
+		}
+		(*e)[strings.TrimSpace(output)] = strings.TrimSpace(style)
+	}
+	return nil
+}
+
+var (
+	fImportPath importPaths
+	fModules    commaList
+	fIgnore     commaList
+	fGenerated  commaList
+	fBinary     commaList
+	fEmailStyle emailStyle
+)
 
 func init() {
 	flag.Var(&fImportPath, "import", "base of import path (i.e. github.com/tredoe); colon-separated list")
+	flag.Var(&fModules, "modules", "directories of modules to create a workspace with go.work; comma-separated list")
+	flag.Var(&fIgnore, "ignore", "fragments of the ignore file; comma-separated list")
+	flag.Var(&fGenerated, "generated", "patterns of generated files (by default, *_gen.go); comma-separated list")
+	flag.Var(&fBinary, "binary", "patterns of binary files (by default, testdata/**); comma-separated list")
+	flag.Var(&fEmailStyle, "email-style", "style of the email by output file (authors, contributors, header, gomod), "+
+		"like authors=plain; comma-separated list of plain, obfuscated or omitted")
 }
 
 // * * *
 
 func usage() {
 	fmt.Fprintf(os.Stderr, `Usage: gowizard -i [-cfg]
+       gowizard <command> [arguments]
 
 `)
 	flag.PrintDefaults()
+
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", c.name, c.short)
+	}
 	os.Exit(2)
 }
 
 func main() {
+	if cmd, args := lookupCommand(os.Args[1:]); cmd != nil {
+		if err := runCommand(cmd, args); err != nil {
+			cmdutil.Fatal(err)
+		}
+		return
+	}
+
 	cfg, err := initConfig()
 	if err != nil {
 		cmdutil.Fatal(err)
@@ -78,18 +147,35 @@ func main() {
 func initConfig() (*wizard.Conf, error) {
 	var (
 		fName    = flag.String("name", "", "project name")
-		fLicense = flag.String("license", "", "license covering the program")
+		fKind    = flag.String("kind", "library", "kind of project, which sets its layout")
+		fLicense = flag.String("license", "", "license covering the program; "+
+			"several ones joined by \"or\" for a dual license, like \"mpl or apache\"")
 		fAuthor  = flag.String("author", "", "author's name")
 		fEmail   = flag.String("email", "", "author's email")
 		fVCS     = flag.String("vcs", "", "version control system")
 		fOrg     = flag.String("org", "", "organization holder of the copyright")
+		fProfile = flag.String("profile", "", "profile of the user configuration; \"none\" to not use any")
+		fReuse   = flag.Bool("reuse", false, "follow the REUSE specification: SPDX headers, and licenses in \"LICENSES\"")
+		fReadme  = flag.String("readme", "", "format of the readme file: markdown (by default) or asciidoc")
+		fYears   = flag.String("copyright-years", "", "policy of the copyright years, to update the headers: "+
+			"\"fixed\" (by default) or \"vcs\", the range of years of the VCS history")
+
+		// Repository
+		fBranch = flag.String("branch", "", "default branch of the repository")
+		fRemote = flag.String("remote", "", "URL of the remote repository, where \"$\" is the program name; "+
+			"\"ssh\" or \"https\" to build it from the import path")
+		fCommit       = flag.Bool("commit", false, "commit the generated files")
+		fCommitMsg    = flag.String("commit-msg", "", "message of the initial commit")
+		fCommitAuthor = flag.String("commit-author", "", "author of the initial commit, like \"Name <email>\"")
 
 		fConfig      = flag.Bool("cfg", false, "add the user configuration file")
 		fInteractive = flag.Bool("i", false, "interactive mode")
 
 		// Listing
+		fListKind    = flag.Bool("lk", false, "list the available kinds of project (for kind flag)")
 		fListLicense = flag.Bool("ll", false, "list the available licenses (for license flag)")
 		fListVCS     = flag.Bool("lv", false, "list the available version control systems (for vcs flag)")
+		fListIgnore  = flag.Bool("li", false, "list the available fragments of the ignore file (for ignore flag)")
 	)
 
 	// == Parse the flags
@@ -101,6 +187,21 @@ func initConfig() (*wizard.Conf, error) {
 	}
 
 	// == Listing
+	if *fListKind {
+		maxLen := 0
+		for _, v := range wizard.ListKindSorted {
+			if len(v) > maxLen {
+				maxLen = len(v)
+			}
+		}
+
+		fmt.Print("  = Kinds of project\n\n")
+		for _, v := range wizard.ListKindSorted {
+			fmt.Printf("  %s: %s%s\n",
+				v, strings.Repeat(" ", maxLen-len(v)), wizard.ListKind[v],
+			)
+		}
+	}
 	if *fListLicense {
 		maxLen := 0
 		for _, v := range wizard.ListLicenseSorted {
@@ -132,19 +233,65 @@ func initConfig() (*wizard.Conf, error) {
 		}
 	}
 
-	if *fListLicense || *fListVCS {
+	if *fListIgnore {
+		maxLen := 0
+		for _, v := range wizard.ListIgnoreSorted {
+			if len(v) > maxLen {
+				maxLen = len(v)
+			}
+		}
+
+		fmt.Print("  = Fragments of the ignore file\n\n")
+		for _, v := range wizard.ListIgnoreSorted {
+			fmt.Printf("  %s: %s%s\n",
+				v, strings.Repeat(" ", maxLen-len(v)), wizard.ListIgnore[v],
+			)
+		}
+	}
+
+	if *fListKind || *fListLicense || *fListVCS || *fListIgnore {
 		return nil, nil
 	}
 
 	var err error
 	cfg := &wizard.Conf{
 		Program:     *fName,
+		Kind:        *fKind,
 		License:     *fLicense,
 		Author:      *fAuthor,
 		Email:       *fEmail,
 		VCS:         *fVCS,
 		ImportPaths: fImportPath,
+		Modules:     fModules,
+		Ignore:      fIgnore,
+		Generated:   fGenerated,
+		Binary:      fBinary,
 		Org:         *fOrg,
+		Profile:     *fProfile,
+		EmailStyle:  fEmailStyle,
+		Reuse:       *fReuse,
+
+		CopyrightYears: *fYears,
+		Readme:         *fReadme,
+
+		Branch:       *fBranch,
+		Remote:       *fRemote,
+		Commit:       *fCommit,
+		CommitMsg:    *fCommitMsg,
+		CommitAuthor: *fCommitAuthor,
+	}
+
+	// Choose the profile at the start, since it sets the values by default.
+	if *fInteractive && !*fConfig && cfg.Profile == "" {
+		names, matched, err := cfg.ProfileNames()
+		if err != nil {
+			return nil, err
+		}
+		if len(names) != 0 {
+			if err = chooseProfile(cfg, names, matched); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Get configuration per user, if any.
@@ -167,6 +314,9 @@ func initConfig() (*wizard.Conf, error) {
 	if err = cfg.PostCheck(*fInteractive, *fConfig); err != nil {
 		return nil, err
 	}
+	if !*fInteractive && !*fConfig {
+		printLicenseWarnings(cfg)
+	}
 
 	// Add configuration.
 	if *fConfig && *fInteractive {
@@ -177,6 +327,38 @@ func initConfig() (*wizard.Conf, error) {
 	return cfg, nil
 }
 
+// printLicenseWarnings prints the warnings about the license of the project.
+func printLicenseWarnings(c *wizard.Conf) {
+	for _, v := range c.LicenseWarnings() {
+		fmt.Fprintf(os.Stderr, "  warning: %s\n", v)
+	}
+}
+
+// chooseProfile asks for the profile of the user configuration to use, being
+// matched the one by default.
+func chooseProfile(c *wizard.Conf, names []string, matched string) (err error) {
+	q := question.New()
+	defer func() {
+		err2 := q.Restore()
+		if err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	if matched == "" {
+		matched = "none"
+	}
+
+	fmt.Printf("\n  = Gowizard :: Profile\n\n")
+
+	q.Prompt("Profile of the user configuration",
+		valid.String(),
+		valid.NewScheme().SetDefault(matched),
+	)
+	c.Profile, err = q.ChoiceString(append(names, "none"))
+	return err
+}
+
 // interactive uses the interactive mode.
 func interactive(c *wizard.Conf, addConfig bool) (err error) {
 	var sFlags []string
@@ -190,6 +372,7 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 		msg = "New project"
 		sFlags = []string{
 			"name",
+			"kind",
 			"org",
 			"author",
 			"email",
@@ -217,9 +400,15 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 		}
 		f.Usage = strings.ToUpper(string(f.Usage[0])) + f.Usage[1:]
 
+		// Show where the default value comes from.
+		usage := f.Usage
+		if origin, ok := c.Origins[k]; ok {
+			usage += " (default from " + origin + ")"
+		}
+
 		switch k {
 		case "name":
-			q.Prompt(f.Usage,
+			q.Prompt(usage,
 				valid.String().SetStringCheck(valid.S_Strict),
 				valid.NewScheme().Required().SetDefault(c.Project),
 			)
@@ -228,6 +417,12 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 			if err = c.SetNames(); err != nil {
 				return err
 			}
+		case "kind":
+			q.Prompt(usage,
+				valid.String(),
+				valid.NewScheme().SetDefault(c.Kind),
+			)
+			c.Kind, err = q.ChoiceString(wizard.ListKindSorted)
 		case "org":
 			isOrg := true
 
@@ -240,41 +435,48 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 			}
 
 			if isOrg {
-				q.Prompt(f.Usage,
+				q.Prompt(usage,
 					valid.String(),
 					valid.NewScheme().Required().SetDefault(c.Org),
 				)
 				c.Org, err = q.ReadString()
 			}
 		case "author":
-			q.Prompt(f.Usage,
+			q.Prompt(usage,
 				valid.String().SetStringCheck(valid.S_Strict),
 				valid.NewScheme().Required().SetDefault(c.Author),
 			)
 			c.Author, err = q.ReadString()
 		case "email":
-			q.Prompt(f.Usage,
+			q.Prompt(usage,
 				valid.Email(),
 				valid.NewScheme().Required().SetDefault(c.Email),
 			)
 			c.Email, err = q.ReadString()
 		case "license":
-			q.Prompt(f.Usage,
+			q.Prompt(usage,
 				valid.String(),
 				valid.NewScheme().SetDefault(wizard.ListLowerLicense[c.License]),
 			)
 			c.License, err = q.ChoiceString(wizard.ListLicenseSorted)
 			// It is got in upper case
 			c.License = strings.ToLower(c.License)
+
+			if err == nil && !addConfig {
+				fmt.Printf("\n  %s: %s\n", wizard.ListLowerLicense[c.License],
+					wizard.LicenseTerms(c.License))
+				printLicenseWarnings(c)
+				fmt.Println()
+			}
 		case "vcs":
-			q.Prompt(f.Usage,
+			q.Prompt(usage,
 				valid.String(),
 				valid.NewScheme().SetDefault(c.VCS),
 			)
 			c.VCS, err = q.ChoiceString(wizard.ListVCSsorted)
 		case "import":
 			if addConfig {
-				q.Prompt(f.Usage,
+				q.Prompt(usage,
 					valid.String(),
 					valid.NewScheme().Required(),
 				)
@@ -283,7 +485,7 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 			} else if len(c.ImportPaths) == 0 {
 				tmp := ""
 
-				q.Prompt(f.Usage,
+				q.Prompt(usage,
 					valid.String(),
 					nil,
 				)
@@ -295,7 +497,7 @@ func interactive(c *wizard.Conf, addConfig bool) (err error) {
 				}
 
 			} else {
-				q.Prompt(f.Usage,
+				q.Prompt(usage,
 					valid.String(),
 					valid.NewScheme().SetDefault(c.ImportPaths[0]),
 				)
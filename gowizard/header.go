@@ -0,0 +1,83 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdHeader = &command{
+	name:  "header",
+	args:  "[-dir directory] [-check] [-update [-years policy]]",
+	short: "Add the license header to the source files which have not it, or update its copyright",
+}
+
+var (
+	fHeaderDir    = cmdHeader.flag.String("dir", ".", "directory of the project")
+	fHeaderCheck  = cmdHeader.flag.Bool("check", false, "list the files without header, without changing them")
+	fHeaderUpdate = cmdHeader.flag.Bool("update", false, "update the holder and years of the copyright line of the headers")
+	fHeaderYears  = cmdHeader.flag.String("years", "", "policy of the copyright years to update: fixed or vcs; "+
+		"by default, the one of the project")
+)
+
+func init() {
+	cmdHeader.run = runHeader
+}
+
+func runHeader(cmd *command, args []string) error {
+	if len(args) != 0 {
+		cmd.usage()
+	}
+
+	if *fHeaderUpdate {
+		return runHeaderUpdate()
+	}
+
+	results, err := wizard.Headers(*fHeaderDir, *fHeaderCheck)
+	if err != nil {
+		return err
+	}
+
+	nMissing := 0
+	for _, v := range results {
+		if v.Status == wizard.HeaderFound {
+			continue
+		}
+		if v.Status == wizard.HeaderMissing {
+			nMissing++
+		}
+		fmt.Printf("  %-8s %s\n", v.Status, v.File)
+	}
+	if nMissing != 0 {
+		return fmt.Errorf("%d file(s) without license header", nMissing)
+	}
+	return nil
+}
+
+func runHeaderUpdate() error {
+	results, err := wizard.UpdateHeaders(*fHeaderDir, *fHeaderYears, *fHeaderCheck)
+	if err != nil {
+		return err
+	}
+
+	nOutdated := 0
+	for _, v := range results {
+		switch v.Status {
+		case wizard.HeaderFound, wizard.HeaderMissing:
+			continue
+		case wizard.HeaderOutdated:
+			nOutdated++
+		}
+		fmt.Printf("  %-8s %s\n", v.Status, v.File)
+	}
+	if nOutdated != 0 {
+		return fmt.Errorf("%d file(s) with the copyright outdated", nOutdated)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdLicensesAudit = &command{
+	name:  "licenses audit",
+	args:  "[directory]",
+	short: "Check the licenses of the dependencies against the project license",
+}
+
+var cmdLicensesDetect = &command{
+	name:  "licenses detect",
+	args:  "[directory]",
+	short: "Detect the license of a project, from its license files or headers",
+}
+
+func init() {
+	cmdLicensesAudit.run = runLicensesAudit
+	cmdLicensesDetect.run = runLicensesDetect
+}
+
+func runLicensesAudit(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	audit, err := wizard.AuditLicenses(dir)
+	if err != nil {
+		return err
+	}
+
+	nConflict, nMissing := 0, 0
+	for _, v := range audit {
+		license := v.License
+		if license == "" {
+			license = "-"
+		}
+		fmt.Printf("  %-8s %-12s %3.0f%%  %s\n", v.Status, license, v.Score*100, v.Module)
+
+		switch v.Status {
+		case wizard.AuditConflict:
+			nConflict++
+		case wizard.AuditMissing:
+			nMissing++
+		}
+	}
+
+	if nMissing != 0 {
+		fmt.Printf("\n  %d module(s) not found in the module cache; run \"go mod download\"\n", nMissing)
+	}
+	if nConflict != 0 {
+		return fmt.Errorf("%d dependency(ies) with a license incompatible with the project", nConflict)
+	}
+	return nil
+}
+
+func runLicensesDetect(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	det, err := wizard.DetectLicense(dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range det.Matches {
+		fmt.Printf("  %-12s %3.0f%%  %s\n", v.License, v.Score*100, v.File)
+	}
+
+	switch {
+	case det.License != "":
+		reuse := ""
+		if det.Reuse {
+			reuse = " (REUSE)"
+		}
+		fmt.Printf("\n  license: %s%s\n", det.License, reuse)
+	case len(det.Matches) != 0:
+		return fmt.Errorf("license %q not available to create the headers", det.Matches[0].License)
+	default:
+		return fmt.Errorf("license not detected")
+	}
+	return nil
+}
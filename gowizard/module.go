@@ -0,0 +1,54 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdAddModule = &command{
+	name:  "add module",
+	args:  "[-dir directory] name...",
+	short: "Add modules to a workspace, registering them in go.work",
+}
+
+var fModuleDir = cmdAddModule.flag.String("dir", ".", "directory of the workspace")
+
+func init() {
+	cmdAddModule.run = runAddModule
+}
+
+func runAddModule(cmd *command, args []string) error {
+	if len(args) == 0 {
+		cmd.usage()
+	}
+
+	changes, err := wizard.AddModule(*fModuleDir, args...)
+	if err != nil {
+		return err
+	}
+	if n := printChanges(changes); n != 0 {
+		return fmt.Errorf("%d file(s) with conflicts; resolve the markers", n)
+	}
+	return nil
+}
+
+// printChanges prints the files changed in the project.
+func printChanges(changes []wizard.UpgradeChange) (nConflict int) {
+	for _, v := range changes {
+		if v.Action == wizard.UpgradeUnchanged {
+			continue
+		}
+		if v.Action == wizard.UpgradeConflict {
+			nConflict++
+		}
+		fmt.Printf("  %-9s %s\n", v.Action, v.File)
+	}
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdNotice = &command{
+	name:  "notice",
+	args:  "[directory]",
+	short: "Rebuild the file NOTICE with the notices of the dependencies",
+}
+
+func init() {
+	cmdNotice.run = runNotice
+}
+
+func runNotice(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	res, err := wizard.RefreshNotice(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range res.Notices {
+		fmt.Printf("  %-8s %s\n", "notice", v)
+	}
+	for _, v := range res.Missing {
+		fmt.Printf("  %-8s %s\n", "missing", v)
+	}
+	if len(res.Missing) != 0 {
+		return fmt.Errorf("%d module(s) not found in the module cache; run \"go mod download\"",
+			len(res.Missing))
+	}
+	return nil
+}
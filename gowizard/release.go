@@ -0,0 +1,50 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdRelease = &command{
+	name:  "release",
+	args:  "[-dir directory] [-date YYYY-MM-DD] [-tag] version",
+	short: "Move the unreleased changes of the changelog to a section for the version",
+}
+
+var (
+	fReleaseDir  = cmdRelease.flag.String("dir", ".", "directory of the project")
+	fReleaseDate = cmdRelease.flag.String("date", "", "date of the release (by default, today)")
+	fReleaseTag  = cmdRelease.flag.Bool("tag", false, "commit the changelog, and tag the version")
+)
+
+func init() {
+	cmdRelease.run = runRelease
+}
+
+func runRelease(cmd *command, args []string) error {
+	if len(args) != 1 {
+		cmd.usage()
+	}
+
+	tag, err := wizard.Release(*fReleaseDir, args[0], wizard.ReleaseOptions{
+		Date: *fReleaseDate,
+		Tag:  *fReleaseTag,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *fReleaseTag {
+		fmt.Printf("  released %s, tagged\n", tag)
+	} else {
+		fmt.Printf("  released %s\n", tag)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdRelicense = &command{
+	name:  "relicense",
+	args:  "-to license [-w] [directory]",
+	short: "Change the license of a project, rewriting its headers and license files",
+}
+
+var (
+	fRelicenseTo    = cmdRelicense.flag.String("to", "", "new license; several ones joined by \"or\" for a dual license")
+	fRelicenseWrite = cmdRelicense.flag.Bool("w", false, "apply the changes; by default, they are only shown")
+)
+
+func init() {
+	cmdRelicense.run = runRelicense
+}
+
+func runRelicense(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+	if *fRelicenseTo == "" {
+		cmd.usage()
+	}
+
+	r, err := wizard.Relicense(dir, *fRelicenseTo)
+	if err != nil {
+		return err
+	}
+
+	// The changes are shown always, to be reviewed before of applying them.
+	os.Stdout.Write(r.Diff())
+
+	for _, v := range r.Skipped {
+		fmt.Fprintf(os.Stderr, "  %-8s %s: header of another license\n", "skipped", v)
+	}
+	for _, v := range r.Missing {
+		fmt.Fprintf(os.Stderr, "  %-8s %s: without license header\n", "missing", v)
+	}
+	if len(r.Changes) == 0 {
+		return nil
+	}
+	if !*fRelicenseWrite {
+		fmt.Fprintf(os.Stderr, "\n  dry run; use the flag -w to apply the changes\n")
+		return nil
+	}
+
+	if err = r.Apply(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "\n  %d file(s) changed to license %q\n", len(r.Changes), r.License)
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdReuseLint = &command{
+	name:  "reuse lint",
+	args:  "[directory]",
+	short: "Check that the project follows the REUSE specification",
+}
+
+func init() {
+	cmdReuseLint.run = runReuseLint
+}
+
+func runReuseLint(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	res, err := wizard.LintReuse(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range res.MissingInfo {
+		fmt.Printf("  %-16s %s\n", "missing info", v)
+	}
+	for _, v := range res.MissingLicenses {
+		fmt.Printf("  %-16s %s\n", "missing license", v)
+	}
+	for _, v := range res.UnusedLicenses {
+		fmt.Printf("  %-16s %s\n", "unused license", v)
+	}
+
+	if !res.Compliant() {
+		return errors.New("the project does not follow the REUSE specification")
+	}
+	fmt.Printf("  %d files follow the REUSE specification\n", res.Files)
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tredoe/wizard"
+)
+
+var cmdUpgrade = &command{
+	name:  "upgrade",
+	args:  "[directory]",
+	short: "Upgrade a project to the current templates",
+}
+
+func init() {
+	cmdUpgrade.run = runUpgrade
+}
+
+func runUpgrade(cmd *command, args []string) error {
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		cmd.usage()
+	}
+
+	changes, err := wizard.Upgrade(dir)
+	if err != nil {
+		return err
+	}
+
+	nConflict := printChanges(changes)
+	if nConflict != 0 {
+		return fmt.Errorf("%d file(s) with conflicts; resolve the markers", nConflict)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// _HEADER_LINES is the number of lines, at the start of a file, where the
+// license header is searched.
+const _HEADER_LINES = 20
+
+var (
+	// Lines which mark a license header.
+	reHeaderMark = regexp.MustCompile(`Copyright|Written in \d+ by|SPDX-License-Identifier`)
+
+	// https://golang.org/s/generatedcode
+	reGenerated = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+	// Parser directives of Dockerfile, which have to be at the top.
+	reDockerDirective = regexp.MustCompile(`(?i)^#\s*(syntax|escape|check)\s*=`)
+)
+
+// HeaderStatus is the status of the license header of a file.
+type HeaderStatus string
+
+// Statuses of the license header
+const (
+	HeaderFound   HeaderStatus = "found"   // the file has a header
+	HeaderMissing HeaderStatus = "missing" // the file has no header
+	HeaderAdded   HeaderStatus = "added"   // the header has been inserted
+
+	HeaderOther    HeaderStatus = "other"    // header of another license; not changed
+	HeaderOutdated HeaderStatus = "outdated" // the copyright line is not the current one
+	HeaderUpdated  HeaderStatus = "updated"  // the copyright line has been updated
+)
+
+// HeaderResult is the license header of a file.
+type HeaderResult struct {
+	File   string // path relative to the project directory
+	Status HeaderStatus
+}
+
+// Headers checks the license header of the source files of the project in
+// "dir", commented with the style of each kind of file. If check is false, the
+// header is inserted in the files which have not it, with the current year; or
+// with the years of the VCS history when the policy of copyright years is
+// "vcs".
+//
+// The hidden files and directories, "vendor" and "testdata" are skipped, as
+// the generated files and the files of unknown kind.
+func Headers(dir string, check bool) ([]HeaderResult, error) {
+	cfg, err := projectConf(dir)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewProject(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.parseLicense()
+	generated := cfg.attributes().generated
+
+	// The years of the files which have history.
+	var history map[string]fileYears
+	if policy, err := cfg.copyrightPolicy(""); err != nil {
+		return nil, err
+	} else if policy == "vcs" && DetectVCS(dir) == "git" {
+		if history, err = gitYears(dir); err != nil {
+			return nil, err
+		}
+	}
+	// The new headers have the year when they are added, not the one of the
+	// creation of the project.
+	created, year := cfg.Year, time.Now().Year()
+	defer func() { cfg.Year, cfg.LastYear = created, 0 }()
+
+	results := make([]HeaderResult, 0)
+
+	err = walkSources(dir, generated, func(name, rel string, data []byte, style CommentStyle) error {
+		res := HeaderResult{rel, HeaderFound}
+		if !hasHeader(data) {
+			res.Status = HeaderMissing
+
+			if !check {
+				cfg.Year, cfg.LastYear = year, 0
+				cfg.setYears(history, rel, 0, 0)
+
+				header, err := p.renderHeader(style)
+				if err != nil {
+					return err
+				}
+				if err = writeFile(name, insertHeader(data, header)); err != nil {
+					return err
+				}
+				res.Status = HeaderAdded
+			}
+		}
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// walkSources calls fn for every source file of the tree in "dir" which can
+// have a license header, with its path relative to dir and its comment style.
+//
+// The hidden files and directories, "vendor" and "testdata" are skipped, as
+// the generated files and the files of unknown kind.
+func walkSources(dir string, generated []string, fn func(name, rel string, data []byte, style CommentStyle) error) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, name)
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && d.Name()[0] == '.' {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == "vendor" || d.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || rel == _REUSE_TOML || matchPatterns(generated, rel) {
+			return nil
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		style, ok := CommentStyleFor(name, data)
+		if !ok || reGenerated.Match(data) {
+			return nil
+		}
+		return fn(name, rel, data, style)
+	})
+}
+
+// hasHeader reports whether the data has a license header at the start.
+func hasHeader(data []byte) bool {
+	lines := bytes.SplitN(data, []byte("\n"), _HEADER_LINES+1)
+	if len(lines) > _HEADER_LINES {
+		lines = lines[:_HEADER_LINES]
+	}
+	for _, line := range lines {
+		if reHeaderMark.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertHeader inserts the header at the start of data, after the lines which
+// have to be the first ones: a shebang, the declaration of XML, or the parser
+// directives of a Dockerfile; separated by a blank line, like headerStart
+// expects.
+func insertHeader(data, header []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	i := headerStart(lines)
+
+	var buf bytes.Buffer
+	if first := strings.TrimRight(strings.Join(lines[:i], ""), " \t\r\n"); first != "" {
+		buf.WriteString(first + "\n\n")
+	}
+	buf.Write(header)
+	if rest := strings.Join(lines[i:], ""); rest != "" {
+		buf.WriteByte('\n')
+		buf.WriteString(rest)
+	}
+	return buf.Bytes()
+}
+
+// findHeader returns the lines of data, and the range of the comment where
+// the license header should be.
+func findHeader(data []byte, style CommentStyle) (lines []string, start, end int) {
+	lines = splitLines(data)
+	start = headerStart(lines)
+	return lines, start, headerBlock(lines, start, style)
+}
+
+// headerMatcher recognizes the license headers rendered by the templates.
+type headerMatcher licenseCorpus
+
+// newHeaderMatcher returns a matcher of the headers of every license, and of
+// the header of the project "p".
+func newHeaderMatcher(p *project) (headerMatcher, error) {
+	corpus, err := headerCorpus(p)
+	if err != nil {
+		return nil, err
+	}
+	// The header of the project, for dual licenses.
+	data, err := p.renderHeader(CommentStyle{})
+	if err != nil {
+		return nil, err
+	}
+	bigrams, size := wordBigrams(string(data))
+	return headerMatcher(append(corpus, licenseText{p.cfg.License, bigrams, size})), nil
+}
+
+// match reports whether the comment is a license header rendered by the
+// templates. A header with only the copyright line is the proprietary one.
+func (m headerMatcher) match(comment string) bool {
+	if !reHeaderMark.MatchString(comment) {
+		return false
+	}
+	if reSPDXLicense.MatchString(comment) {
+		return true
+	}
+	if _, size := wordBigrams(comment); size == 0 {
+		return true
+	}
+	license, _ := licenseCorpus(m).classify(comment)
+	return license != ""
+}
+
+// headerStart returns the index of the line where the license header starts,
+// after of a shebang, the declaration of XML, or the parser directives of a
+// Dockerfile, and the blank lines after of them.
+func headerStart(lines []string) int {
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if (i == 0 && (strings.HasPrefix(line, "#!") || strings.HasPrefix(line, "<?xml"))) ||
+			reDockerDirective.MatchString(line) {
+			continue
+		}
+		break
+	}
+	if i == 0 {
+		return 0
+	}
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) == ""; i++ {
+	}
+	return i
+}
+
+// headerBlock returns the range of lines of the comment which starts at the
+// line "start", commented with the given style. The comment finishes at the
+// end of the block, or before the first line which is not commented.
+func headerBlock(lines []string, start int, style CommentStyle) (end int) {
+	if start >= len(lines) {
+		return start
+	}
+
+	if style.Start != "" {
+		if strings.TrimSpace(lines[start]) != strings.TrimSpace(style.Start) {
+			return start
+		}
+		mark := strings.TrimSpace(style.End)
+		for end = start + 1; end < len(lines); end++ {
+			if strings.Contains(lines[end], mark) {
+				return end + 1
+			}
+		}
+		return start // not closed
+	}
+
+	prefix := strings.TrimSpace(style.Line)
+	for end = start; end < len(lines); end++ {
+		if !strings.HasPrefix(strings.TrimSpace(lines[end]), prefix) {
+			break
+		}
+	}
+	return end
+}
+
+// matchPatterns reports whether the file "name", relative to the project
+// directory, matches any pattern of Git. The patterns without slashes match
+// the base name.
+func matchPatterns(patterns []string, name string) bool {
+	for _, v := range patterns {
+		v = strings.TrimSuffix(v, "**")
+		v = strings.TrimSuffix(v, "/")
+
+		target := name
+		if !strings.Contains(v, "/") {
+			target = path.Base(name)
+		} else {
+			v = strings.TrimPrefix(v, "/")
+		}
+		if ok, _ := path.Match(v, target); ok {
+			return true
+		}
+		if strings.HasPrefix(name, v+"/") { // directory
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertHeader(t *testing.T) {
+	header := "# Copyright 2020 Jane Doe\n#\n# SPDX-License-Identifier: MPL-2.0\n"
+	style := ListCommentStyle["hash"]
+
+	tests := []struct {
+		name string
+		data string
+		out  string
+	}{
+		{"empty", "", header},
+		{"plain", "echo hi\n", header + "\necho hi\n"},
+		{"shebang", "#!/bin/sh\necho hi\n", "#!/bin/sh\n\n" + header + "\necho hi\n"},
+		{"shebang and blank lines", "#!/bin/sh\n\n\necho hi\n", "#!/bin/sh\n\n" + header + "\necho hi\n"},
+		{"only shebang", "#!/bin/sh", "#!/bin/sh\n\n" + header},
+		{"xml", "<?xml version=\"1.0\"?>\n<a/>\n", "<?xml version=\"1.0\"?>\n\n" + header + "\n<a/>\n"},
+		{"dockerfile", "# syntax=docker/dockerfile:1\n# escape=`\n\nFROM scratch\n",
+			"# syntax=docker/dockerfile:1\n# escape=`\n\n" + header + "\nFROM scratch\n"},
+	}
+
+	for _, tt := range tests {
+		data := insertHeader([]byte(tt.data), []byte(header))
+		if string(data) != tt.out {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, data, tt.out)
+			continue
+		}
+
+		// The header inserted is found.
+		if !hasHeader(data) {
+			t.Errorf("%s: header not found", tt.name)
+		}
+		lines, start, end := findHeader(data, style)
+		if got := strings.Join(lines[start:end], ""); got != header {
+			t.Errorf("%s: got header\n%s\nwant\n%s", tt.name, got, header)
+		}
+	}
+}
+
+func TestHeadersYear(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{Year: 2020})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	writeTestFile(t, filepath.Join(dir, "run.sh"), "#!/bin/sh\n\necho hi\n")
+	writeTestFile(t, filepath.Join(dir, "util.go"), "package test\n")
+
+	results, err := Headers(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range results {
+		want := HeaderFound
+		if v.File == "run.sh" || v.File == "util.go" {
+			want = HeaderAdded
+		}
+		if v.Status != want {
+			t.Errorf("%s: got status %q, want %q", v.File, v.Status, want)
+		}
+	}
+
+	// The new headers have the current year.
+	year := strconv.Itoa(time.Now().Year())
+	for _, name := range []string{"run.sh", "util.go"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "Copyright "+year+" ") {
+			t.Errorf("%s: without year %s:\n%s", name, year, data)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "run.sh")); !strings.HasPrefix(string(data), "#!/bin/sh\n\n# Copyright") {
+		t.Errorf("run.sh: header not after the shebang:\n%s", data)
+	}
+
+	// Then, they are found.
+	if results, err = Headers(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range results {
+		if v.Status != HeaderFound {
+			t.Errorf("%s: got status %q", v.File, v.Status)
+		}
+	}
+}
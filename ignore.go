@@ -0,0 +1,201 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Fragments of the ignore file
+var (
+	ListIgnoreSorted = []string{"binary", "coverage", "editors", "go", "ide", "os", "vendor"}
+
+	ListIgnore = map[string]string{
+		"binary":   "program built by \"go build\"",
+		"coverage": "coverage profiles and reports",
+		"editors":  "backup and swap files of editors",
+		"go":       "test binaries, profiles and shared libraries",
+		"ide":      "settings of IDEs",
+		"os":       "files generated by the operating systems",
+		"vendor":   "vendored dependencies",
+	}
+
+	// defaultIgnore are the fragments used when none is set.
+	defaultIgnore = []string{"binary", "coverage", "editors", "go", "os"}
+)
+
+// _IGNORE_NONE is the fragment to not ignore any file.
+const _IGNORE_NONE = "none"
+
+// The fragments are templates with the patterns in the syntax of Git.
+var ignoreFragments = map[string]string{
+	"binary": `/{{.Program}}
+{{- if or (eq .Kind "library-with-command") (eq .Kind "service")}}
+/cmd/{{.Program}}/{{.Program}}
+{{- end}}
+`,
+	"coverage": `*.out
+*.coverprofile
+coverage.html
+`,
+	"editors": `*~
+*.swp
+*.swo
+\#*\#
+.#*
+`,
+	"go": `*.test
+*.prof
+*.pprof
+*.exe
+*.exe~
+*.dll
+*.so
+*.dylib
+`,
+	"ide": `.idea/
+.vscode/
+*.iml
+`,
+	"os": `.DS_Store
+._*
+Thumbs.db
+Desktop.ini
+`,
+	"vendor": `/vendor/
+`,
+}
+
+// checkIgnore checks the fragments of the ignore file.
+func checkIgnore(list []string) error {
+	for _, v := range list {
+		if _, ok := ListIgnore[v]; !ok && v != _IGNORE_NONE {
+			return fmt.Errorf("unavailable ignore fragment: %q; valid: %s, %s",
+				v, strings.Join(ListIgnoreSorted, ", "), _IGNORE_NONE)
+		}
+	}
+	return nil
+}
+
+// IgnoreFragments returns the fragments of the ignore file, sorted.
+func (c *Conf) IgnoreFragments() []string {
+	list := c.Ignore
+	if len(list) == 0 {
+		list = defaultIgnore
+	}
+
+	selected := make(map[string]bool)
+	for _, v := range list {
+		selected[v] = true
+	}
+	if selected[_IGNORE_NONE] {
+		return nil
+	}
+
+	fragments := make([]string, 0, len(list))
+	for _, v := range ListIgnoreSorted {
+		if selected[v] {
+			fragments = append(fragments, v)
+		}
+	}
+	return fragments
+}
+
+// parseIgnore parses the templates of the fragments of the ignore file.
+func (p *project) parseIgnore() {
+	for name, text := range ignoreFragments {
+		p.tmpl = template.Must(p.tmpl.New("Ignore-" + name).Parse(text))
+	}
+}
+
+// renderIgnore renders the patterns to ignore files, with the syntax of the
+// VCS. Returns nil without VCS.
+func (p *project) renderIgnore() ([]byte, error) {
+	v, ok := vcsBackends[p.cfg.VCS]
+	if !ok {
+		return nil, nil
+	}
+	syntax := v.IgnoreSyntax()
+
+	var buf bytes.Buffer
+	if syntax == IgnoreHgSyntax {
+		buf.WriteString("syntax: glob\n\n")
+	}
+
+	for i, name := range p.cfg.IgnoreFragments() {
+		data, err := p.renderVar("Ignore-" + name)
+		if err != nil {
+			return nil, fmt.Errorf("ignore file: %s", err)
+		}
+
+		if syntax == IgnoreGitSyntax || syntax == IgnoreHgSyntax {
+			if i != 0 {
+				buf.WriteByte('\n')
+			}
+			desc := ListIgnore[name]
+			fmt.Fprintf(&buf, "# %s\n", strings.ToUpper(desc[:1])+desc[1:])
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			for _, pattern := range translateIgnore(syntax, line) {
+				buf.WriteString(pattern + "\n")
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// translateIgnore translates a pattern of Git to the syntax given. Returns
+// nil if it can not be expressed in it.
+func translateIgnore(syntax, pattern string) []string {
+	if syntax == IgnoreGitSyntax {
+		return []string{pattern}
+	}
+	if pattern[0] == '!' {
+		return nil // no negation
+	}
+
+	// Mercurial uses the same escape for comment characters.
+	if syntax != IgnoreHgSyntax {
+		pattern = strings.Replace(pattern, `\#`, "#", -1)
+	}
+
+	isDir := strings.HasSuffix(pattern, "/")
+	rooted := pattern[0] == '/'
+	pattern = strings.Trim(pattern, "/")
+	// Without slashes, the pattern matches in any directory.
+	rooted = rooted || strings.Contains(pattern, "/")
+
+	switch syntax {
+	case IgnoreHgSyntax:
+		if rooted {
+			return []string{"rootglob:" + pattern}
+		}
+		return []string{pattern}
+
+	case IgnoreGlobSyntax:
+		if isDir {
+			pattern += "/*"
+		}
+		if rooted || pattern[0] == '*' {
+			return []string{pattern}
+		}
+		return []string{pattern, "*/" + pattern}
+
+	case IgnoreSvnSyntax:
+		if strings.Contains(pattern, "/") {
+			return nil
+		}
+		return []string{pattern}
+	}
+	return nil
+}
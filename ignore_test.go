@@ -0,0 +1,92 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTranslateIgnore(t *testing.T) {
+	tests := []struct {
+		syntax  string
+		pattern string
+		out     []string
+	}{
+		{IgnoreGitSyntax, "*.out", []string{"*.out"}},
+		{IgnoreGitSyntax, "/hello", []string{"/hello"}},
+		{IgnoreGitSyntax, `\#*\#`, []string{`\#*\#`}},
+		{IgnoreGitSyntax, "!keep.out", []string{"!keep.out"}},
+
+		{IgnoreHgSyntax, "*.out", []string{"*.out"}},
+		{IgnoreHgSyntax, "/hello", []string{"rootglob:hello"}},
+		{IgnoreHgSyntax, "/vendor/", []string{"rootglob:vendor"}},
+		{IgnoreHgSyntax, "/cmd/hello/hello", []string{"rootglob:cmd/hello/hello"}},
+		{IgnoreHgSyntax, ".idea/", []string{".idea"}},
+		{IgnoreHgSyntax, `\#*\#`, []string{`\#*\#`}},
+		{IgnoreHgSyntax, "!keep.out", nil},
+
+		{IgnoreGlobSyntax, "*.out", []string{"*.out"}},
+		{IgnoreGlobSyntax, "/hello", []string{"hello"}},
+		{IgnoreGlobSyntax, "/vendor/", []string{"vendor/*"}},
+		{IgnoreGlobSyntax, ".idea/", []string{".idea/*", "*/.idea/*"}},
+		{IgnoreGlobSyntax, ".DS_Store", []string{".DS_Store", "*/.DS_Store"}},
+		{IgnoreGlobSyntax, `\#*\#`, []string{"#*#", "*/#*#"}},
+		{IgnoreGlobSyntax, "!keep.out", nil},
+
+		{IgnoreSvnSyntax, "*.out", []string{"*.out"}},
+		{IgnoreSvnSyntax, "/hello", []string{"hello"}},
+		{IgnoreSvnSyntax, ".idea/", []string{".idea"}},
+		{IgnoreSvnSyntax, "/cmd/hello/hello", nil},
+		{IgnoreSvnSyntax, `\#*\#`, []string{"#*#"}},
+		{IgnoreSvnSyntax, "!keep.out", nil},
+	}
+
+	for _, tt := range tests {
+		if out := translateIgnore(tt.syntax, tt.pattern); !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%s %q: got %q, want %q", tt.syntax, tt.pattern, out, tt.out)
+		}
+	}
+}
+
+func TestRenderIgnore(t *testing.T) {
+	setDataDir(t)
+
+	tests := []struct {
+		vcs      string
+		contains []string
+		excludes []string
+	}{
+		{"git", []string{"# Program built by \"go build\"\n/hello\n", "\\#*\\#\n"}, nil},
+		{"hg", []string{"syntax: glob\n\n", "rootglob:hello\n"}, []string{"/hello"}},
+		{"fossil", []string{"\nhello\n", "*/.DS_Store\n"}, []string{"# ", "/hello"}},
+		{"svn", []string{"\nhello\n", "#*#\n"}, []string{"# ", "/hello"}},
+		{"jj", []string{"/hello\n"}, nil},
+	}
+
+	for _, tt := range tests {
+		p := newTestProject(t, &Conf{Project: "Hello", VCS: tt.vcs})
+		p.parseIgnore()
+
+		data, err := p.renderIgnore()
+		if err != nil {
+			t.Fatalf("%s: %s", tt.vcs, err)
+		}
+		out := "\n" + string(data)
+		for _, v := range tt.contains {
+			if !strings.Contains(out, v) {
+				t.Errorf("%s: without %q:\n%s", tt.vcs, v, data)
+			}
+		}
+		for _, v := range tt.excludes {
+			if strings.Contains(out, v) {
+				t.Errorf("%s: with %q:\n%s", tt.vcs, v, data)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// _LICENSE_MIN_SCORE is the minimum similarity to classify a text as a
+// license.
+const _LICENSE_MIN_SCORE = 0.75
+
+// licenseText is the text of a license of the corpus.
+type licenseText struct {
+	name    string         // base name of the file, like "MPL" or "MIT"
+	bigrams map[string]int // pairs of words
+	size    int
+}
+
+// licenseCorpus has the texts of the licenses in the data directory: the
+// ones used by the projects, and the most common ones of the dependencies.
+type licenseCorpus []licenseText
+
+// loadLicenseCorpus loads the texts of the licenses in "dataDir".
+func loadLicenseCorpus(dataDir string) (licenseCorpus, error) {
+	files, err := filepath.Glob(filepath.Join(dataDir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	corpus := make(licenseCorpus, 0, len(files))
+	for _, v := range files {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			return nil, fmt.Errorf("license corpus: %s", err)
+		}
+		bigrams, size := wordBigrams(string(data))
+		corpus = append(corpus, licenseText{
+			strings.TrimSuffix(filepath.Base(v), ".txt"), bigrams, size,
+		})
+	}
+	return corpus, nil
+}
+
+// classify returns the name of the license more similar to the text, and the
+// similarity, between 0 and 1. Returns an empty name if no license reaches the
+// minimum similarity.
+func (corpus licenseCorpus) classify(text string) (name string, score float64) {
+	bigrams, size := wordBigrams(text)
+	if size == 0 {
+		return "", 0
+	}
+
+	for _, l := range corpus {
+		common := 0
+		for k, n := range bigrams {
+			if m := l.bigrams[k]; m < n {
+				common += m
+			} else {
+				common += n
+			}
+		}
+		// Dice coefficient
+		if s := 2 * float64(common) / float64(size+l.size); s > score {
+			name, score = l.name, s
+		}
+	}
+	if score < _LICENSE_MIN_SCORE {
+		return "", score
+	}
+	return name, score
+}
+
+// wordBigrams returns the pairs of consecutive words of the text, in lower
+// case, and its number. The copyright lines are skipped, since they change
+// between projects.
+func wordBigrams(text string) (map[string]int, int) {
+	words := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.ToLower(strings.TrimLeft(line, " \t#/*-;!<>"))
+		if strings.HasPrefix(trimmed, "copyright") || strings.HasPrefix(trimmed, "spdx-filecopyrighttext") ||
+			strings.HasPrefix(trimmed, "written in") {
+			continue
+		}
+		words = append(words, strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+
+	bigrams := make(map[string]int)
+	size := 0
+	for i := 1; i < len(words); i++ {
+		bigrams[words[i-1]+" "+words[i]]++
+		size++
+	}
+	return bigrams, size
+}
+
+// * * *
+
+// Strength of the copyleft of a license: the terms which it imposes to the
+// works which use it.
+const (
+	copyleftProprietary = iota - 1 // all rights reserved
+	copyleftNone                   // permissive, or public domain
+	copyleftWeak                   // by file
+	copyleftStrong                 // the whole program
+	copyleftNetwork                // the whole program, also used over a network
+)
+
+// licenseTerms are the terms of a license which matter for its compatibility.
+type licenseTerms struct {
+	copyleft int
+	patent   bool // grants a license of the patents of the contributors
+}
+
+// licenseModel has the terms of the licenses of projects, and of the ones of
+// the corpus, by name in lower case.
+var licenseModel = map[string]licenseTerms{
+	"agpl":   {copyleftNetwork, true},
+	"apache": {copyleftNone, true},
+	"cc0":    {copyleftNone, false}, // it does not waive patents
+	"gpl":    {copyleftStrong, true},
+	"mpl":    {copyleftWeak, true},
+	"none":   {copyleftProprietary, false},
+
+	"bsd-2-clause": {copyleftNone, false},
+	"bsd-3-clause": {copyleftNone, false},
+	"isc":          {copyleftNone, false},
+	"mit":          {copyleftNone, false},
+}
+
+// licenseSPDX are the identifiers of SPDX of the licenses, by name in lower
+// case.
+var licenseSPDX = map[string]string{
+	"agpl":   "AGPL-3.0-or-later",
+	"apache": "Apache-2.0",
+	"cc0":    "CC0-1.0",
+	"gpl":    "GPL-3.0-or-later",
+	"mpl":    "MPL-2.0",
+	"none":   "LicenseRef-Proprietary",
+
+	"bsd-2-clause": "BSD-2-Clause",
+	"bsd-3-clause": "BSD-3-Clause",
+	"isc":          "ISC",
+	"mit":          "MIT",
+}
+
+// spdxExpression returns the license expression of SPDX for the licenses.
+func spdxExpression(licenses []string) string {
+	ids := make([]string, 0, len(licenses))
+	for _, v := range licenses {
+		ids = append(ids, licenseSPDX[v])
+	}
+	return strings.Join(ids, " OR ")
+}
+
+// licenseCompatible reports whether a work under the license "dep" can be
+// used in a project under the license "project". A work with strong copyleft
+// can only be used in projects whose copyleft is as strong as its one.
+// The unknown licenses are considered compatible.
+func licenseCompatible(project, dep string) bool {
+	p, ok1 := licenseModel[strings.ToLower(project)]
+	d, ok2 := licenseModel[strings.ToLower(dep)]
+	if !ok1 || !ok2 || d.copyleft < copyleftStrong {
+		return true
+	}
+	return p.copyleft >= d.copyleft
+}
+
+// depLicenseConflict reports whether a dependency under the license "dep", a
+// name of the corpus, can not be used in a project under "license", which can
+// be dual.
+func depLicenseConflict(license, dep string) bool {
+	for _, v := range splitLicense(license) {
+		if !licenseCompatible(v, dep) {
+			return true
+		}
+	}
+	return false
+}
+
+// * * *
+
+// reLicenseOr splits a dual license, like "mpl or apache", where the user
+// chooses any of them.
+var reLicenseOr = regexp.MustCompile(`(?i)\s+or\s+`)
+
+// _LICENSE_OR joins the licenses of a dual license.
+const _LICENSE_OR = " or "
+
+// splitLicense returns the licenses of a license expression.
+func splitLicense(expr string) []string {
+	return reLicenseOr.Split(strings.TrimSpace(expr), -1)
+}
+
+// checkLicense checks the license, which can be dual, returning it in lower
+// case.
+func checkLicense(expr string) (string, error) {
+	list := splitLicense(strings.ToLower(expr))
+
+	for _, v := range list {
+		if _, ok := ListLowerLicense[v]; !ok {
+			return "", fmt.Errorf("unavailable license: %q; valid: %s, or several ones joined by \"or\"",
+				v, strings.Join(sortedKeys(ListLowerLicense), ", "))
+		}
+	}
+	return strings.Join(list, _LICENSE_OR), nil
+}
+
+// Licenses returns the licenses of the project; several ones if it is dual.
+func (c *Conf) Licenses() []string {
+	if c.License == "" {
+		return nil
+	}
+	return splitLicense(c.License)
+}
+
+// setLicenseNames sets the full names of the licenses, and the expression of
+// SPDX, to pass to templates.
+func (c *Conf) setLicenseNames() {
+	c.FullLicenses = make([]string, 0)
+	for _, v := range c.Licenses() {
+		if v != "none" {
+			c.FullLicenses = append(c.FullLicenses, ListLicense[ListLowerLicense[v]])
+		}
+	}
+	c.FullLicense = strings.Join(c.FullLicenses, _LICENSE_OR)
+	c.SPDX = spdxExpression(c.Licenses())
+}
+
+// LicenseTerms describes the terms of the license "name".
+func LicenseTerms(name string) string {
+	t, ok := licenseModel[strings.ToLower(name)]
+	if !ok {
+		return ""
+	}
+
+	var s string
+	switch t.copyleft {
+	case copyleftProprietary:
+		return "proprietary: all rights reserved; nobody can use it without permission"
+	case copyleftNone:
+		s = "permissive: the programs which use it can be under any license"
+	case copyleftWeak:
+		s = "weak copyleft: the changes to its files have to be under the same license, " +
+			"but they can be combined with files under any license"
+	case copyleftStrong:
+		s = "strong copyleft: the programs which use it have to be distributed " +
+			"under the same license, with their source code"
+	case copyleftNetwork:
+		s = "network copyleft: like GPL, but the source code has to be offered " +
+			"also to the users of the program over a network"
+	}
+	if t.patent {
+		s += "; grants the patents of the contributors"
+	} else {
+		s += "; does not grant patents"
+	}
+	return s
+}
+
+// LicenseWarnings returns the warnings about the license chosen for the
+// project: choices which usually are not desired, and dual licenses whose
+// options contradict each other.
+func (c *Conf) LicenseWarnings() []string {
+	warnings := make([]string, 0)
+	list := c.Licenses()
+
+	seen := make(map[string]bool)
+	for _, v := range list {
+		if seen[v] {
+			warnings = append(warnings, fmt.Sprintf("license %q is repeated", ListLowerLicense[v]))
+		}
+		seen[v] = true
+	}
+
+	if seen["agpl"] && ((c.Org != "" && len(seen) == 1) || seen["none"]) {
+		warnings = append(warnings, "AGPL obliges to offer the source code to the users of "+
+			"the program over a network, even when it is not distributed; "+
+			"it is usually avoided by organizations with proprietary services")
+	}
+	if seen["gpl"] && len(seen) == 1 && (c.Kind == "" || strings.HasPrefix(c.Kind, "library")) {
+		warnings = append(warnings, "a library under GPL can only be imported by programs "+
+			"under GPL; MPL lets them use any license")
+	}
+
+	// == Dual license
+
+	if len(seen) > 1 {
+		if seen["none"] {
+			warnings = append(warnings, "dual license with the proprietary one: "+
+				"anybody can choose the free license, so the copies are not restricted")
+		}
+		if seen["cc0"] {
+			warnings = append(warnings, "dual license with CC0: the work is dedicated to the "+
+				"public domain, so the other licenses have no effect")
+		}
+		if seen["gpl"] && seen["agpl"] {
+			warnings = append(warnings, "dual license with GPL and AGPL: anybody can choose GPL, "+
+				"so the network clause of AGPL has no effect")
+		}
+	}
+	return warnings
+}
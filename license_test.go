@@ -0,0 +1,179 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyCorpus(t *testing.T) {
+	corpus, err := loadLicenseCorpus("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corpus) == 0 {
+		t.Fatal("empty corpus")
+	}
+
+	for _, l := range corpus {
+		data, err := os.ReadFile(filepath.Join("data", l.name+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := string(data)
+
+		// Variants which have to be classified as the license.
+		commented := "// " + strings.Replace(text, "\n", "\n// ", -1)
+		variants := map[string]string{
+			"text":      text,
+			"copyright": "Copyright (c) 2024 Jane Doe <jane@example.com>\n\n" + text,
+			"commented": commented,
+			"reflowed":  strings.Join(strings.Fields(text), " "),
+			"uppercase": strings.ToUpper(text),
+		}
+		for k, v := range variants {
+			if name, score := corpus.classify(v); name != l.name {
+				t.Errorf("%s (%s): got %q (%.2f)", l.name, k, name, score)
+			}
+		}
+
+		// Near misses: the text truncated to the half.
+		words := strings.Fields(text)
+		half := strings.Join(words[:len(words)/2], " ")
+		if name, score := corpus.classify(half); name != "" {
+			t.Errorf("%s (half): got %q (%.2f)", l.name, name, score)
+		}
+	}
+}
+
+func TestClassifyNearMiss(t *testing.T) {
+	corpus, err := loadLicenseCorpus("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"one word", "MIT"},
+		{"copyright only", "Copyright 2024 Jane Doe\nSPDX-FileCopyrightText: 2024 Jane Doe\n"},
+		{"paragraph", `This program prints the list of files changed in the
+repository since the last release, sorted by name, and the number of lines
+added and removed in each one.`},
+		{"license reference", `Licensed under the MIT License; see the file LICENSE
+for the terms, or the Apache License, Version 2.0, at your choice.`},
+		{"permission notice", `Permission is hereby granted to use this program
+for any purpose, provided that this notice is kept in all the copies.`},
+	}
+
+	for _, tt := range tests {
+		if name, score := corpus.classify(tt.text); name != "" {
+			t.Errorf("%s: got %q (%.2f)", tt.name, name, score)
+		}
+	}
+}
+
+func TestLicenseCompatible(t *testing.T) {
+	tests := []struct {
+		project, dep string
+		want         bool
+	}{
+		{"mpl", "mit", true},
+		{"none", "bsd-3-clause", true},
+		{"apache", "mpl", true},
+		{"none", "mpl", true},
+		{"mpl", "gpl", false},
+		{"apache", "GPL", false},
+		{"none", "gpl", false},
+		{"gpl", "gpl", true},
+		{"agpl", "gpl", true},
+		{"gpl", "agpl", false},
+		{"agpl", "agpl", true},
+		{"mpl", "unknown", true},
+		{"unknown", "agpl", true},
+	}
+
+	for _, tt := range tests {
+		if got := licenseCompatible(tt.project, tt.dep); got != tt.want {
+			t.Errorf("licenseCompatible(%q, %q): got %v, want %v", tt.project, tt.dep, got, tt.want)
+		}
+	}
+}
+
+func TestDepLicenseConflict(t *testing.T) {
+	tests := []struct {
+		license, dep string
+		want         bool
+	}{
+		{"mpl", "mit", false},
+		{"gpl", "gpl", false},
+		{"mpl", "gpl", true},
+		// Every option of a dual license has to be compatible.
+		{"gpl or mpl", "gpl", true},
+		{"gpl OR agpl", "agpl", true},
+		{"agpl or gpl", "gpl", false},
+		{"mpl or apache", "isc", false},
+	}
+
+	for _, tt := range tests {
+		if got := depLicenseConflict(tt.license, tt.dep); got != tt.want {
+			t.Errorf("depLicenseConflict(%q, %q): got %v, want %v", tt.license, tt.dep, got, tt.want)
+		}
+	}
+}
+
+func TestLicenseWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Conf
+		want []string // prefixes of the warnings
+	}{
+		{"mpl", Conf{License: "mpl"}, nil},
+		{"agpl", Conf{License: "agpl"}, nil},
+		{"agpl with org", Conf{License: "agpl", Org: "Example Inc."},
+			[]string{"AGPL obliges"}},
+		{"agpl or mpl with org", Conf{License: "agpl or mpl", Org: "Example Inc."}, nil},
+		{"gpl library", Conf{License: "gpl", Kind: "library"},
+			[]string{"a library under GPL"}},
+		{"gpl library with command", Conf{License: "gpl", Kind: "library-with-command"},
+			[]string{"a library under GPL"}},
+		{"gpl without kind", Conf{License: "gpl"},
+			[]string{"a library under GPL"}},
+		{"gpl command", Conf{License: "gpl", Kind: "command"}, nil},
+		{"gpl service", Conf{License: "gpl", Kind: "service"}, nil},
+		{"gpl or mpl library", Conf{License: "gpl or mpl", Kind: "library"}, nil},
+		{"none or mpl", Conf{License: "none or mpl"},
+			[]string{"dual license with the proprietary one"}},
+		{"none or agpl", Conf{License: "none or agpl"},
+			[]string{"AGPL obliges", "dual license with the proprietary one"}},
+		{"cc0 or apache", Conf{License: "cc0 or apache"},
+			[]string{"dual license with CC0"}},
+		{"gpl or agpl", Conf{License: "gpl or agpl", Kind: "command"},
+			[]string{"dual license with GPL and AGPL"}},
+		{"repeated", Conf{License: "mpl or mpl"},
+			[]string{`license "MPL" is repeated`}},
+		{"none", Conf{License: "none"}, nil},
+	}
+
+	for _, tt := range tests {
+		got := tt.cfg.LicenseWarnings()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %q, want %d warnings", tt.name, got, len(tt.want))
+			continue
+		}
+		for i := range tt.want {
+			if !strings.HasPrefix(got[i], tt.want[i]) {
+				t.Errorf("%s: got %q, want prefix %q", tt.name, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Labels used in the conflict markers.
+const (
+	_MERGE_CURRENT = "current"
+	_MERGE_BASE    = "previous template"
+	_MERGE_NEW     = "new template"
+)
+
+// splitLines splits data in lines, keeping the newline at the end of each one.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchLines returns, for every line in a, the index of the line matched in b
+// by the longest common subsequence, or -1 if there is not.
+func matchLines(a, b []string) []int {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+// merge3 does a three-way merge of the lines changed from base in both current
+// and next. Where both have changed the same region in a different way, it is
+// written between conflict markers, and it is returned conflict as true.
+func merge3(base, current, next []byte) (out []byte, conflict bool) {
+	b := splitLines(base)
+	c := splitLines(current)
+	n := splitLines(next)
+
+	matchC := matchLines(b, c)
+	matchN := matchLines(b, n)
+
+	var buf bytes.Buffer
+	writeLines := func(lines []string) {
+		for _, v := range lines {
+			buf.WriteString(v)
+		}
+	}
+
+	ib, ic, in := 0, 0, 0
+	for {
+		// Find the next line of base kept in both versions.
+		sync := ib
+		for ; sync < len(b); sync++ {
+			if matchC[sync] != -1 && matchN[sync] != -1 {
+				break
+			}
+		}
+
+		endC, endN := len(c), len(n)
+		if sync < len(b) {
+			endC, endN = matchC[sync], matchN[sync]
+		}
+
+		chunkB, chunkC, chunkN := b[ib:sync], c[ic:endC], n[in:endN]
+
+		switch {
+		case equalLines(chunkB, chunkC):
+			writeLines(chunkN)
+		case equalLines(chunkB, chunkN), equalLines(chunkC, chunkN):
+			writeLines(chunkC)
+		default:
+			conflict = true
+			writeConflict(&buf, _MERGE_CURRENT, "<<<<<<< ", chunkC)
+			writeConflict(&buf, _MERGE_BASE, "||||||| ", chunkB)
+			writeConflict(&buf, "", "=======", chunkN)
+			buf.WriteString(">>>>>>> " + _MERGE_NEW + "\n")
+		}
+
+		if sync == len(b) {
+			break
+		}
+		buf.WriteString(b[sync])
+		ib, ic, in = sync+1, endC+1, endN+1
+	}
+
+	return buf.Bytes(), conflict
+}
+
+// writeConflict writes a conflict marker followed by the lines of one side.
+func writeConflict(buf *bytes.Buffer, label, marker string, lines []string) {
+	buf.WriteString(marker)
+	if label != "" {
+		buf.WriteString(label)
+	}
+	buf.WriteByte('\n')
+
+	for _, v := range lines {
+		buf.WriteString(v)
+		if !strings.HasSuffix(v, "\n") {
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// equalLines reports whether a and b have the same lines.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// _DIFF_CONTEXT is the number of lines of context around the changes of a
+// unified diff.
+const _DIFF_CONTEXT = 3
+
+// diffLine is a line of a diff: kept (' '), removed ('-') or added ('+').
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the changes from a to b of the file "name", in unified
+// format. A nil a is a new file, and a nil b is a removed file.
+func unifiedDiff(name string, a, b []byte) []byte {
+	la, lb := splitLines(a), splitLines(b)
+
+	// The common lines at both ends are not compared.
+	pre := 0
+	for pre < len(la) && pre < len(lb) && la[pre] == lb[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(la)-pre && suf < len(lb)-pre && la[len(la)-1-suf] == lb[len(lb)-1-suf] {
+		suf++
+	}
+	if pre == len(la) && pre == len(lb) {
+		return nil
+	}
+
+	lines := make([]diffLine, 0, len(la)+len(lb))
+	for _, v := range la[:pre] {
+		lines = append(lines, diffLine{' ', v})
+	}
+	midA, midB := la[pre:len(la)-suf], lb[pre:len(lb)-suf]
+	match := matchLines(midA, midB)
+	j := 0
+	for i, v := range midA {
+		if match[i] == -1 {
+			lines = append(lines, diffLine{'-', v})
+			continue
+		}
+		for ; j < match[i]; j++ {
+			lines = append(lines, diffLine{'+', midB[j]})
+		}
+		lines = append(lines, diffLine{' ', v})
+		j++
+	}
+	for ; j < len(midB); j++ {
+		lines = append(lines, diffLine{'+', midB[j]})
+	}
+	for _, v := range la[len(la)-suf:] {
+		lines = append(lines, diffLine{' ', v})
+	}
+
+	var buf bytes.Buffer
+	if a == nil {
+		buf.WriteString("--- /dev/null\n")
+	} else {
+		buf.WriteString("--- a/" + name + "\n")
+	}
+	if b == nil {
+		buf.WriteString("+++ /dev/null\n")
+	} else {
+		buf.WriteString("+++ b/" + name + "\n")
+	}
+
+	// Hunks: the changes closer than twice the context are joined.
+	nA, nB := 0, 0 // lines of a and b before the line i
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			nA++
+			nB++
+			i++
+			continue
+		}
+
+		start := i - _DIFF_CONTEXT
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for kept := 0; end < len(lines) && kept <= 2*_DIFF_CONTEXT; end++ {
+			if lines[end].op == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+		}
+		// The context after of the last change.
+		for end > i && lines[end-1].op == ' ' {
+			end--
+		}
+		if end += _DIFF_CONTEXT; end > len(lines) {
+			end = len(lines)
+		}
+
+		startA, startB := nA-(i-start), nB-(i-start)
+		countA, countB := 0, 0
+		for _, v := range lines[start:end] {
+			if v.op != '+' {
+				countA++
+			}
+			if v.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(startA, countA), hunkRange(startB, countB))
+		for _, v := range lines[start:end] {
+			buf.WriteByte(v.op)
+			buf.WriteString(v.text)
+			if !strings.HasSuffix(v.text, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		nA += countA - (i - start)
+		nB += countB - (i - start)
+		i = end
+	}
+	return buf.Bytes()
+}
+
+// hunkRange returns the range of lines of a hunk, starting at the line with
+// index "start".
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
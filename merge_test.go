@@ -0,0 +1,199 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, current, next string
+		out                 string
+		conflict            bool
+	}{
+		{"unchanged", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nc\n", false},
+		{"only template", "a\nb\nc\n", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", false},
+		{"only user", "a\nb\nc\n", "a\nB\nc\n", "a\nb\nc\n", "a\nB\nc\n", false},
+		{"same change", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", "a\nB\nc\n", false},
+		{"both, apart",
+			"a\nb\nc\nd\ne\n",
+			"A\nb\nc\nd\ne\n",
+			"a\nb\nc\nd\nE\n",
+			"A\nb\nc\nd\nE\n", false},
+		{"user adds, template removes",
+			"a\nb\nc\nd\n",
+			"a\nb\nc\nd\nuser\n",
+			"a\nc\nd\n",
+			"a\nc\nd\nuser\n", false},
+		{"conflict",
+			"a\nb\nc\n",
+			"a\nuser\nc\n",
+			"a\ntemplate\nc\n",
+			"a\n<<<<<<< current\nuser\n||||||| previous template\nb\n=======\ntemplate\n>>>>>>> new template\nc\n",
+			true},
+		{"conflict without newline at end",
+			"a\nb",
+			"a\nuser",
+			"a\ntemplate",
+			"a\n<<<<<<< current\nuser\n||||||| previous template\nb\n=======\ntemplate\n>>>>>>> new template\n",
+			true},
+	}
+
+	for _, tt := range tests {
+		out, conflict := merge3([]byte(tt.base), []byte(tt.current), []byte(tt.next))
+		if string(out) != tt.out || conflict != tt.conflict {
+			t.Errorf("%s: got (%v)\n%s\nwant (%v)\n%s", tt.name, conflict, out, tt.conflict, tt.out)
+		}
+	}
+}
+
+func TestUpgradeFile(t *testing.T) {
+	const deleted = "\x00" // the file does not exist
+
+	tests := []struct {
+		name                string
+		base, current, next string
+		action              UpgradeAction
+		out                 string
+	}{
+		{"same content", "a\n", "b\n", "b\n", UpgradeUnchanged, "b\n"},
+		{"template not changed", "a\n", "user\n", "a\n", UpgradeUnchanged, "user\n"},
+		{"untouched by the user", "a\nb\n", "a\nb\n", "a\nB\n", UpgradeUpdated, "a\nB\n"},
+		{"clean merge", "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n", UpgradeMerged, "A\nb\nC\n"},
+		{"conflict", "a\nb\nc\n", "a\nuser\nc\n", "a\ntemplate\nc\n", UpgradeConflict,
+			"a\n<<<<<<< current\nuser\n||||||| previous template\nb\n=======\ntemplate\n>>>>>>> new template\nc\n"},
+		{"new in the templates", "", deleted, "a\n", UpgradeAdded, "a\n"},
+		{"deleted by the user", "a\n", deleted, "b\n", UpgradeSkipped, deleted},
+
+		// The base is stored by its hash.
+		{"hash untouched by the user", fileHash([]byte("a\n")), "a\n", "b\n", UpgradeUpdated, "b\n"},
+		{"hash template not changed", fileHash([]byte("a\n")), "user\n", "a\n", UpgradeUnchanged, "user\n"},
+		{"hash unknown", fileHash([]byte("a\n")), "user\n", "b\n", UpgradeConflict,
+			"<<<<<<< current\nuser\n||||||| previous template\n=======\nb\n>>>>>>> new template\n"},
+		{"hash deleted by the user", fileHash([]byte("a\n")), deleted, "b\n", UpgradeSkipped, deleted},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		dst := filepath.Join(dir, string(rune('a'+i)))
+		if tt.current != deleted {
+			writeTestFile(t, dst, tt.current)
+		}
+
+		action, err := upgradeFile(dst, tt.base, []byte(tt.next))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if action != tt.action {
+			t.Errorf("%s: got action %q, want %q", tt.name, action, tt.action)
+		}
+
+		data, err := os.ReadFile(dst)
+		switch {
+		case tt.out == deleted:
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: file created", tt.name)
+			}
+		case err != nil:
+			t.Errorf("%s: %s", tt.name, err)
+		case string(data) != tt.out:
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, data, tt.out)
+		}
+	}
+}
+
+func TestCopiedLicense(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"LICENSE-MPL.txt", true},
+		{"LICENSES/MPL-2.0.txt", true},
+		{"LICENSES/LicenseRef-Proprietary.txt", false},
+		{"LICENSE", false},
+		{"docs/LICENSE-MPL.txt", false},
+		{"NOTICE", false},
+		{"README.md", false},
+	}
+
+	for _, tt := range tests {
+		if got := copiedLicense(tt.name); got != tt.want {
+			t.Errorf("copiedLicense(%q): got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestManifestLicenseText(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{License: "mpl or apache"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	// The texts of the licenses are stored by their hash.
+	data, err := os.ReadFile(filepath.Join(dir, _MANIFEST))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Mozilla Public License Version 2.0") {
+		t.Error("manifest with the text of the license")
+	}
+	m, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LICENSE-MPL.txt", "LICENSE-Apache.txt"} {
+		text, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Files[name]; got != fileHash(text) {
+			t.Errorf("%s: got %.40q", name, got)
+		}
+	}
+	if !strings.Contains(m.Files[_README], "Test") {
+		t.Errorf("readme not stored:\n%s", m.Files[_README])
+	}
+
+	// The license edited by the user is kept, and the one deleted is not
+	// added again.
+	writeTestFile(t, filepath.Join(dir, "LICENSE-MPL.txt"), "Edited\n")
+	if err = os.Remove(filepath.Join(dir, "LICENSE-Apache.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Upgrade(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range changes {
+		want := UpgradeUnchanged
+		if v.File == "LICENSE-Apache.txt" {
+			want = UpgradeSkipped
+		}
+		if v.Action != want {
+			t.Errorf("%s: got action %q, want %q", v.File, v.Action, want)
+		}
+	}
+	if data, err = os.ReadFile(filepath.Join(dir, "LICENSE-MPL.txt")); err != nil || string(data) != "Edited\n" {
+		t.Errorf("edited license: got %q, %v", data, err)
+	}
+	if data, err = os.ReadFile(filepath.Join(dir, _MANIFEST)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Mozilla Public License Version 2.0") {
+		t.Error("manifest with the text of the license after of upgrading")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// _NOTICE is the file of attribution notices, used by the Apache License.
+const _NOTICE = "NOTICE"
+
+// _NOTICE_SEPARATOR starts the notice of every dependency.
+const _NOTICE_SEPARATOR = "========================================================================"
+
+// Names of the notice file of dependencies, in order of preference.
+var noticeFiles = []string{"NOTICE", "NOTICE.txt", "NOTICE.md"}
+
+// NoticeResult is the result of refreshing the notice file.
+type NoticeResult struct {
+	Notices []Module // dependencies with a notice
+	Missing []Module // dependencies not found in the module cache
+}
+
+// RefreshNotice rebuilds the notice file of the project in "dir",
+// adding after of its own notice the ones of the modules required in its
+// "go.mod" files, which are got from the local module cache.
+// The project must have the Apache License, which is the one that uses it.
+func RefreshNotice(dir string) (*NoticeResult, error) {
+	cfg, err := projectConf(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(cfg.Licenses(), "apache") {
+		return nil, fmt.Errorf("%s: the notice file is only used by the Apache License; license: %q",
+			dir, cfg.License)
+	}
+
+	// The own notice is kept, if the file exists.
+	file := filepath.Join(dir, _NOTICE)
+	own, err := os.ReadFile(file)
+	if err == nil {
+		if i := bytes.Index(own, []byte("\n"+_NOTICE_SEPARATOR+"\n")); i != -1 {
+			own = append(bytes.TrimRight(own[:i], "\n"), '\n')
+		}
+	} else if os.IsNotExist(err) {
+		p, err := NewProject(cfg)
+		if err != nil {
+			return nil, err
+		}
+		p.parseLicense()
+		p.parseProject()
+
+		if own, err = p.renderVar("Notice"); err != nil {
+			return nil, fmt.Errorf("%s: %s", _NOTICE, err)
+		}
+	} else {
+		return nil, err
+	}
+
+	// == Dependencies
+
+	deps, err := requiredModules(dir, cfg.Modules)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(NoticeResult)
+	var buf bytes.Buffer
+	buf.Write(own)
+
+	for _, mod := range deps {
+		modDir := moduleDir(mod)
+		if _, err = os.Stat(modDir); err != nil {
+			res.Missing = append(res.Missing, mod)
+			continue
+		}
+
+		for _, name := range noticeFiles {
+			data, err := os.ReadFile(filepath.Join(modDir, name))
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&buf, "\n%s\n%s\n%s\n\n", _NOTICE_SEPARATOR, mod, _NOTICE_SEPARATOR)
+			buf.Write(bytes.TrimSpace(data))
+			buf.WriteByte('\n')
+
+			res.Notices = append(res.Notices, mod)
+			break
+		}
+	}
+
+	if err = writeFile(file, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRefreshNotice(t *testing.T) {
+	setDataDir(t)
+
+	cache := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+	writeTestFile(t, filepath.Join(cache, "example.com", "dep@v1.2.0", "NOTICE.txt"),
+		"Dep\nCopyright 2020 The Dep Authors\n\n")
+	writeTestFile(t, filepath.Join(cache, "example.com", "!upper@v1.0.0", "NOTICE"),
+		"Upper\n")
+	writeTestFile(t, filepath.Join(cache, "example.com", "plain@v0.3.0", "LICENSE"),
+		"MIT License\n")
+
+	p := newTestProject(t, &Conf{License: "apache", ImportPaths: []string{"example.com/jane"}})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	err := appendFile(filepath.Join(dir, "go.mod"), `
+require (
+	example.com/Upper v1.0.0
+	example.com/dep v1.2.0
+	example.com/missing v0.1.0
+	example.com/plain v0.3.0
+)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The own notice is changed by the user.
+	own, err := os.ReadFile(filepath.Join(dir, _NOTICE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	own = append(own, "\nThis product includes the logo of Jane.\n"...)
+	writeTestFile(t, filepath.Join(dir, _NOTICE), string(own))
+
+	want := string(own) + `
+` + _NOTICE_SEPARATOR + `
+example.com/Upper v1.0.0
+` + _NOTICE_SEPARATOR + `
+
+Upper
+
+` + _NOTICE_SEPARATOR + `
+example.com/dep v1.2.0
+` + _NOTICE_SEPARATOR + `
+
+Dep
+Copyright 2020 The Dep Authors
+`
+
+	// The second refresh replaces the notices of the dependencies, and keeps
+	// the own one.
+	for i := 0; i < 2; i++ {
+		res, err := RefreshNotice(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []Module{{"example.com/Upper", "v1.0.0"}, {"example.com/dep", "v1.2.0"}}; !reflect.DeepEqual(res.Notices, want) {
+			t.Errorf("#%d: notices: got %v, want %v", i, res.Notices, want)
+		}
+		if want := []Module{{"example.com/missing", "v0.1.0"}}; !reflect.DeepEqual(res.Missing, want) {
+			t.Errorf("#%d: missing: got %v, want %v", i, res.Missing, want)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, _NOTICE))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("#%d: got:\n%s\nwant:\n%s", i, data, want)
+		}
+	}
+
+	// Without the file, the own notice is rendered.
+	if err = os.Remove(filepath.Join(dir, _NOTICE)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RefreshNotice(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, _NOTICE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "Test\n") || strings.Contains(string(data), "logo of Jane") {
+		t.Errorf("got:\n%s", data)
+	}
+}
+
+func TestRefreshNoticeLicense(t *testing.T) {
+	setDataDir(t)
+
+	for _, license := range []string{"gpl", "mpl"} {
+		p := newTestProject(t, &Conf{Project: "Test" + license, License: license})
+		if err := p.Create(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := RefreshNotice(p.cfg.Program)
+		if err == nil || !strings.Contains(err.Error(), "only used by the Apache License") {
+			t.Errorf("%s: got error %v", license, err)
+		}
+		if _, err = os.Stat(filepath.Join(p.cfg.Program, _NOTICE)); !os.IsNotExist(err) {
+			t.Errorf("%s: notice file written", license)
+		}
+	}
+
+	// A dual license with the Apache one.
+	p := newTestProject(t, &Conf{Project: "Dual", License: "mpl or apache"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RefreshNotice(p.cfg.Program); err != nil {
+		t.Errorf("dual license: %s", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of configuration values, like "work" or "personal",
+// defined in a configuration file. Its values have precedence over the rest of
+// values of the same file.
+type Profile struct {
+	Org     string   `yaml:"org,omitempty"`
+	Author  string   `yaml:"author,omitempty"`
+	Email   string   `yaml:"email,omitempty"`
+	License string   `yaml:"license,omitempty"`
+	VCS     string   `yaml:"vcs,omitempty"`
+	Import  []string `yaml:"import,omitempty"`
+	Branch  string   `yaml:"branch,omitempty"`
+	Remote  string   `yaml:"remote,omitempty"`
+	Ignore  []string `yaml:"ignore,omitempty"`
+
+	EmailStyle map[string]string `yaml:"email_style,omitempty"`
+
+	Generated []string `yaml:"generated,omitempty"`
+	Binary    []string `yaml:"binary,omitempty"`
+
+	CopyrightYears string `yaml:"copyright_years,omitempty"`
+	Readme         string `yaml:"readme,omitempty"`
+
+	// Prefixes of the target directory or import path which select the
+	// profile when none is given.
+	Match []string `yaml:"match,omitempty"`
+}
+
+// conf returns the configuration values of the profile.
+func (p *Profile) conf() *Conf {
+	return &Conf{
+		Org:         p.Org,
+		Author:      p.Author,
+		Email:       p.Email,
+		License:     p.License,
+		VCS:         p.VCS,
+		ImportPaths: p.Import,
+		Branch:      p.Branch,
+		Remote:      p.Remote,
+		Ignore:      p.Ignore,
+		Generated:   p.Generated,
+		Binary:      p.Binary,
+
+		CopyrightYears: p.CopyrightYears,
+		Readme:         p.Readme,
+		EmailStyle:     p.EmailStyle,
+	}
+}
+
+// configLayer is a configuration file already read.
+type configLayer struct {
+	file string
+	cfg  *Conf
+}
+
+// readConfigLayers reads the configuration files which exist, from the
+// highest precedence to the lowest.
+func readConfigLayers() ([]configLayer, error) {
+	layers := make([]configLayer, 0)
+
+	for _, file := range configFiles() {
+		cfg, err := readConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			layers = append(layers, configLayer{file, cfg})
+		}
+	}
+	return layers, nil
+}
+
+// ProfileNames returns the names of the profiles defined in the configuration
+// files, sorted, and the one which matches the current directory or the
+// import path, if any.
+func (c *Conf) ProfileNames() (names []string, matched string, err error) {
+	layers, err := readConfigLayers()
+	if err != nil {
+		return nil, "", err
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range layers {
+		for name := range l.cfg.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	matched, _ = c.matchProfile(layers)
+	return names, matched, nil
+}
+
+// selectProfile sets the profile to use, if it has not been set, looking for
+// the environment variable GOWIZARD_PROFILE, and then for the profile which
+// matches the current directory or the import path.
+// The profile "none" is used to not select any.
+func (c *Conf) selectProfile(layers []configLayer) error {
+	if c.Profile == "none" {
+		return nil
+	}
+	if c.Profile == "" {
+		if v := os.Getenv(_ENV_PREFIX + "PROFILE"); v != "" {
+			c.Profile = v
+			c.setOrigin("profile", "$"+_ENV_PREFIX+"PROFILE")
+		} else if name, prefix := c.matchProfile(layers); name != "" {
+			c.Profile = name
+			c.setOrigin("profile", "match "+prefix)
+		}
+		if c.Profile == "" {
+			return nil
+		}
+	}
+
+	for _, l := range layers {
+		if _, ok := l.cfg.Profiles[c.Profile]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("profile not found: %q", c.Profile)
+}
+
+// matchProfile returns the profile with the longest prefix which matches
+// either the current directory or the import path, and that prefix.
+func (c *Conf) matchProfile(layers []configLayer) (name, prefix string) {
+	targets := make([]string, 0, 2)
+
+	if dir, err := filepath.Abs("."); err == nil {
+		targets = append(targets, filepath.ToSlash(dir))
+	}
+	if len(c.ImportPaths) != 0 && c.ImportPaths[0] != "" {
+		targets = append(targets, c.ImportPaths[0])
+	}
+
+	home, _ := os.UserHomeDir()
+	longest := 0
+
+	for _, l := range layers {
+		pNames := make([]string, 0, len(l.cfg.Profiles))
+		for k := range l.cfg.Profiles {
+			pNames = append(pNames, k)
+		}
+		sort.Strings(pNames)
+
+		for _, pName := range pNames {
+			for _, m := range l.cfg.Profiles[pName].Match {
+				expanded := m
+				if strings.HasPrefix(m, "~/") && home != "" {
+					expanded = filepath.ToSlash(filepath.Join(home, m[2:]))
+				}
+
+				for _, t := range targets {
+					if hasPathPrefix(t, expanded) && len(expanded) > longest {
+						name, prefix, longest = pName, m, len(expanded)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// hasPathPrefix reports whether the path s starts with the path prefix.
+func hasPathPrefix(s, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return prefix != "" && (s == prefix || strings.HasPrefix(s, prefix+"/"))
+}
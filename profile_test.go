@@ -0,0 +1,194 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProfileConfig = `version: 2
+author: Jane Doe
+email: jane@home.example
+license: mpl
+readme: markdown
+profiles:
+  work:
+    org: Example Inc.
+    email: jane@example.com
+    license: apache
+    readme: asciidoc
+    copyright_years: vcs
+    email_style:
+      header: omitted
+    match:
+      - ~/src/example.com
+      - example.com/
+  team:
+    org: Example Team
+    match:
+      - ~/src/example.com/team
+      - example.com/team/
+  oss:
+    license: gpl
+`
+
+// setProfileConfig writes the user configuration with profiles in a temporary
+// home, and changes to the directory "dir" of that home.
+func setProfileConfig(t *testing.T, dir string) {
+	t.Helper()
+	home := setConfigHome(t)
+	t.Setenv(_ENV_PREFIX+"PROFILE", "")
+
+	file, err := userConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, file, testProfileConfig)
+
+	dir = filepath.Join(home, dir)
+	if err = os.MkdirAll(dir, _DIR_PERM); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSelectProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string // directory of the home
+		env     string // value of GOWIZARD_PROFILE
+		profile string // set by flag
+		imports []string
+
+		want   string
+		origin string
+		org    string
+		email  string
+	}{
+		{"no match", "src/other", "", "", nil,
+			"", "", "", "jane@home.example"},
+		{"match dir", "src/example.com/app", "", "", nil,
+			"work", "match ~/src/example.com", "Example Inc.", "jane@example.com"},
+		{"match import", "src/other", "", "", []string{"example.com/lib"},
+			"work", "match example.com/", "Example Inc.", "jane@example.com"},
+		{"longest dir", "src/example.com/team/app", "", "", nil,
+			"team", "match ~/src/example.com/team", "Example Team", "jane@home.example"},
+		{"longest import", "src/other", "", "", []string{"example.com/team/lib"},
+			"team", "match example.com/team/", "Example Team", "jane@home.example"},
+		{"env", "src/example.com/app", "oss", "", nil,
+			"oss", "$GOWIZARD_PROFILE", "", "jane@home.example"},
+		{"flag", "src/example.com/app", "oss", "team", nil,
+			"team", "flag", "Example Team", "jane@home.example"},
+		{"none", "src/example.com/app", "", "none", nil,
+			"none", "flag", "", "jane@home.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setProfileConfig(t, tt.dir)
+			if tt.env != "" {
+				t.Setenv(_ENV_PREFIX+"PROFILE", tt.env)
+			}
+
+			cfg := &Conf{Profile: tt.profile, ImportPaths: tt.imports}
+			if err := cfg.UserConfig(); err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.Profile != tt.want {
+				t.Errorf("profile: got %q, want %q", cfg.Profile, tt.want)
+			}
+			if tt.origin != "" && cfg.Origins["profile"] != tt.origin {
+				t.Errorf("origin: got %q, want %q", cfg.Origins["profile"], tt.origin)
+			}
+			if cfg.Org != tt.org {
+				t.Errorf("org: got %q, want %q", cfg.Org, tt.org)
+			}
+			if cfg.Email != tt.email {
+				t.Errorf("email: got %q, want %q", cfg.Email, tt.email)
+			}
+			if cfg.Author != "Jane Doe" {
+				t.Errorf("author: got %q", cfg.Author)
+			}
+		})
+	}
+}
+
+func TestProfileValues(t *testing.T) {
+	setProfileConfig(t, "src/example.com/app")
+
+	cfg := new(Conf)
+	if err := cfg.UserConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.License != "apache" || cfg.Readme != "asciidoc" || cfg.CopyrightYears != "vcs" {
+		t.Errorf("got license %q, readme %q, copyright years %q",
+			cfg.License, cfg.Readme, cfg.CopyrightYears)
+	}
+	if cfg.EmailStyle["header"] != "omitted" {
+		t.Errorf("email style: got %q", cfg.EmailStyle)
+	}
+	if got := cfg.Origins["readme"]; !strings.HasSuffix(got, "(profile work)") {
+		t.Errorf("origin of readme: got %q", got)
+	}
+}
+
+func TestProfileNotFound(t *testing.T) {
+	setProfileConfig(t, "src/other")
+	t.Setenv(_ENV_PREFIX+"PROFILE", "home")
+
+	err := new(Conf).UserConfig()
+	if err == nil || err.Error() != `profile not found: "home"` {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	setProfileConfig(t, "src/example.com/team")
+
+	names, matched, err := new(Conf).ProfileNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "oss,team,work" {
+		t.Errorf("names: got %q", names)
+	}
+	if matched != "team" {
+		t.Errorf("matched: got %q", matched)
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	tests := []struct {
+		s, prefix string
+		want      bool
+	}{
+		{"example.com/lib", "example.com", true},
+		{"example.com/lib", "example.com/", true},
+		{"example.com", "example.com/", true},
+		{"example.company/lib", "example.com", false},
+		{"example.com/lib", "", false},
+		{"/home/jane/src", "/home/jane/src/app", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasPathPrefix(tt.s, tt.prefix); got != tt.want {
+			t.Errorf("hasPathPrefix(%q, %q): got %v, want %v", tt.s, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"path"
+	"strings"
+)
+
+// _README_ADOC is the readme file in AsciiDoc.
+const _README_ADOC = "README.adoc"
+
+// _IMPORT_PLACEHOLDER is used when the import path is not set.
+const _IMPORT_PLACEHOLDER = "<< IMPORT PATH >>"
+
+// Files of continuous integration, by host of the repository.
+const (
+	_CI_GITHUB = ".github/workflows/ci.yml"
+	_CI_GITLAB = ".gitlab-ci.yml"
+)
+
+// Formats of the readme file
+var (
+	ListReadmeSorted = []string{"asciidoc", "markdown"}
+
+	ListReadme = map[string]string{
+		"asciidoc": "AsciiDoc, in \"" + _README_ADOC + "\"",
+		"markdown": "Markdown, in \"" + _README + "\"",
+	}
+)
+
+// readmeFile returns the name of the readme file, by its format.
+func (c *Conf) readmeFile() string {
+	if c.Readme == "asciidoc" {
+		return _README_ADOC
+	}
+	return _README
+}
+
+// Badge is an image with a link, shown at the top of the readme file.
+type Badge struct {
+	Name  string
+	Image string
+	Link  string
+}
+
+// Badges returns the badges of the project, derived from its import path: the
+// reference in pkg.go.dev, the state of the continuous integration and the
+// coverage of the tests for the projects hosted in GitHub or GitLab, whose
+// file of CI is created, and the report of Go Report Card.
+// The proprietary projects have not the badges of the public services.
+func (c *Conf) Badges() []Badge {
+	if c.ImportPath == "" {
+		return nil
+	}
+	public := !containsString(c.Licenses(), "none")
+	badges := make([]Badge, 0)
+
+	if public {
+		badges = append(badges, Badge{
+			"Go Reference",
+			"https://pkg.go.dev/badge/" + c.ImportPath + ".svg",
+			"https://pkg.go.dev/" + c.ImportPath,
+		})
+	}
+
+	if host, repo := c.repository(); host != "" {
+		ownerRepo := strings.TrimPrefix(repo, host+"/")
+
+		switch host {
+		case "github.com":
+			badges = append(badges,
+				Badge{
+					"CI",
+					"https://" + repo + "/actions/workflows/ci.yml/badge.svg",
+					"https://" + repo + "/actions/workflows/ci.yml",
+				},
+				Badge{
+					"Coverage",
+					"https://codecov.io/gh/" + ownerRepo + "/graph/badge.svg",
+					"https://codecov.io/gh/" + ownerRepo,
+				},
+			)
+		case "gitlab.com":
+			branch := c.Branch
+			if branch == "" {
+				branch = "main"
+			}
+			badges = append(badges,
+				Badge{
+					"CI",
+					"https://" + repo + "/badges/" + branch + "/pipeline.svg",
+					"https://" + repo + "/-/pipelines",
+				},
+				Badge{
+					"Coverage",
+					"https://" + repo + "/badges/" + branch + "/coverage.svg",
+					"https://" + repo + "/-/pipelines",
+				},
+			)
+		}
+	}
+
+	if public {
+		badges = append(badges, Badge{
+			"Go Report Card",
+			"https://goreportcard.com/badge/" + c.ImportPath,
+			"https://goreportcard.com/report/" + c.ImportPath,
+		})
+	}
+	return badges
+}
+
+// repository returns the host and the path of the repository, given by the
+// host, the owner and the project of the import path. They are empty if the
+// import path has not them.
+func (c *Conf) repository() (host, repo string) {
+	parts := strings.Split(c.ImportPath, "/")
+	if len(parts) < 3 {
+		return "", ""
+	}
+	return parts[0], path.Join(parts[:3]...)
+}
+
+// ciFile returns the name of the file of continuous integration for the host
+// of the repository, and the name of its template. They are empty if the host
+// is not supported.
+func (c *Conf) ciFile() (name, tmplName string) {
+	switch host, _ := c.repository(); host {
+	case "github.com":
+		return _CI_GITHUB, "CIGitHub"
+	case "gitlab.com":
+		return _CI_GITLAB, "CIGitLab"
+	}
+	return "", ""
+}
+
+// TestPackages returns the patterns of the packages to test. In a workspace,
+// they are the ones of every module, since its root is not a module.
+func (c *Conf) TestPackages() string {
+	if len(c.Modules) == 0 {
+		return "./..."
+	}
+	list := make([]string, 0, len(c.Modules))
+	for _, v := range c.Modules {
+		list = append(list, "./"+v+"/...")
+	}
+	return strings.Join(list, " ")
+}
+
+// DocPath returns the import path used to link the documentation.
+func (c *Conf) DocPath() string {
+	if c.ImportPath == "" {
+		return _IMPORT_PLACEHOLDER
+	}
+	return c.ImportPath
+}
+
+// moduleRoots returns the import paths of the modules of the project.
+func (c *Conf) moduleRoots() []string {
+	root := c.DocPath()
+	if len(c.Modules) == 0 {
+		return []string{root}
+	}
+
+	roots := make([]string, 0, len(c.Modules))
+	for _, v := range c.Modules {
+		roots = append(roots, root+"/"+v)
+	}
+	return roots
+}
+
+// Packages returns the import paths of the packages to import, for the kinds
+// with a library.
+func (c *Conf) Packages() []string {
+	switch c.Kind {
+	case "", "library", "library-with-command":
+		return c.moduleRoots()
+	}
+	return nil
+}
+
+// Commands returns the import paths of the programs to install, for the kinds
+// with a command or a service. The program of every module of a workspace is
+// named as its directory.
+func (c *Conf) Commands() []string {
+	list := make([]string, 0)
+
+	for _, v := range c.moduleRoots() {
+		program := c.Program
+		if len(c.Modules) != 0 {
+			program = path.Base(v)
+		}
+
+		switch c.Kind {
+		case "command":
+			list = append(list, v)
+		case "library-with-command", "service":
+			list = append(list, v+"/cmd/"+program)
+		}
+	}
+	return list
+}
@@ -0,0 +1,180 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		kind    string
+		modules []string
+		want    string
+	}{
+		{"library", nil, ""},
+		{"command", nil, "example.com/tool"},
+		{"library-with-command", nil, "example.com/tool/cmd/tool"},
+		{"service", nil, "example.com/tool/cmd/tool"},
+		{"command", []string{"a", "lib/b"}, "example.com/tool/a example.com/tool/lib/b"},
+		{"library-with-command", []string{"a", "lib/b"},
+			"example.com/tool/a/cmd/a example.com/tool/lib/b/cmd/b"},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{Program: "tool", ImportPath: "example.com/tool", Kind: tt.kind, Modules: tt.modules}
+		if got := strings.Join(c.Commands(), " "); got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.kind, tt.modules, got, tt.want)
+		}
+	}
+}
+
+func TestBadges(t *testing.T) {
+	tests := []struct {
+		importPath string
+		license    string
+		want       string // names of the badges
+	}{
+		{"", "mpl", ""},
+		{"example.com/tool", "mpl", "Go Reference|Go Report Card"},
+		{"example.com/tool", "none", ""},
+		{"github.com/jane/tool", "mpl", "Go Reference|CI|Coverage|Go Report Card"},
+		{"github.com/jane/tool/v2", "mpl", "Go Reference|CI|Coverage|Go Report Card"},
+		{"github.com/jane", "mpl", "Go Reference|Go Report Card"},
+		{"gitlab.com/jane/tool", "none", "CI|Coverage"},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{ImportPath: tt.importPath, License: tt.license}
+		names := make([]string, 0)
+		for _, v := range c.Badges() {
+			names = append(names, v.Name)
+		}
+		if got := strings.Join(names, "|"); got != tt.want {
+			t.Errorf("%q (%s): got %q, want %q", tt.importPath, tt.license, got, tt.want)
+		}
+	}
+
+	// The links of the hosts.
+	c := &Conf{ImportPath: "gitlab.com/jane/tool", License: "mpl", Branch: "trunk"}
+	for _, v := range c.Badges() {
+		if v.Name == "CI" && v.Image != "https://gitlab.com/jane/tool/badges/trunk/pipeline.svg" {
+			t.Errorf("GitLab CI: got image %q", v.Image)
+		}
+	}
+	c = &Conf{ImportPath: "github.com/jane/tool/v2", License: "mpl"}
+	for _, v := range c.Badges() {
+		if v.Name == "CI" && v.Link != "https://github.com/jane/tool/actions/workflows/ci.yml" {
+			t.Errorf("GitHub CI: got link %q", v.Link)
+		}
+		if v.Name == "Coverage" && v.Link != "https://codecov.io/gh/jane/tool" {
+			t.Errorf("GitHub coverage: got link %q", v.Link)
+		}
+	}
+}
+
+func TestDocPath(t *testing.T) {
+	if got := (&Conf{}).DocPath(); got != _IMPORT_PLACEHOLDER {
+		t.Errorf("without import path: got %q", got)
+	}
+	if got := (&Conf{ImportPath: "example.com/tool"}).DocPath(); got != "example.com/tool" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTestPackages(t *testing.T) {
+	if got := (&Conf{}).TestPackages(); got != "./..." {
+		t.Errorf("module: got %q", got)
+	}
+	if got := (&Conf{Modules: []string{"a", "lib/b"}}).TestPackages(); got != "./a/... ./lib/b/..." {
+		t.Errorf("workspace: got %q", got)
+	}
+}
+
+func TestCIFile(t *testing.T) {
+	setDataDir(t)
+
+	tests := []struct {
+		importPath string
+		readme     string
+		modules    []string
+		files      map[string]string // content expected by file
+	}{
+		{"example.com", "", nil, map[string]string{
+			"README.md": "\tgo test ./...\n",
+		}},
+		{"github.com/jane", "", nil, map[string]string{
+			"README.md": "\tgo test ./...\n",
+			_CI_GITHUB: "      - run: go test -coverprofile=coverage.out ./...\n" +
+				"      - uses: codecov/codecov-action@v4\n        with:\n" +
+				"          files: coverage.out\n          token: ${{ secrets.CODECOV_TOKEN }}\n",
+		}},
+		{"gitlab.com/jane", "asciidoc", []string{"a", "lib/b"}, map[string]string{
+			_README_ADOC: "----\ngo test ./a/... ./lib/b/...\n----\n",
+			_CI_GITLAB:   "    - go test -coverprofile=coverage.out ./a/... ./lib/b/...\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := newTestProject(t, &Conf{
+			Project: "Hello", ImportPath: tt.importPath + "/hello", Readme: tt.readme, Modules: tt.modules, GoVersion: "1.22",
+		})
+		p.parseLicense()
+		p.parseProject()
+		files, err := p.render()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rendered := make(map[string]string)
+		for _, f := range files {
+			rendered[f.name] = string(f.data)
+		}
+		for name, want := range tt.files {
+			if !strings.Contains(rendered[name], want) {
+				t.Errorf("%s: %s without %q:\n%s", tt.importPath, name, want, rendered[name])
+			}
+		}
+		for _, name := range []string{_CI_GITHUB, _CI_GITLAB} {
+			if _, ok := tt.files[name]; !ok && rendered[name] != "" {
+				t.Errorf("%s: %s created", tt.importPath, name)
+			}
+			var v map[string]interface{}
+			if err = yaml.Unmarshal([]byte(rendered[name]), &v); err != nil {
+				t.Errorf("%s: %s: %s", tt.importPath, name, err)
+			}
+		}
+	}
+}
+
+func TestAddModuleCI(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{
+		Project: "Hello", ImportPaths: []string{"github.com/jane"}, Modules: []string{"api"},
+	})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if _, err := AddModule(dir, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(_CI_GITHUB)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "      - run: go test -coverprofile=coverage.out ./api/... ./worker/...\n"; !strings.Contains(string(data), want) {
+		t.Errorf("%s: without %q:\n%s", _CI_GITHUB, want, data)
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RelicenseChange is a file changed to relicense a project.
+type RelicenseChange struct {
+	File string // path relative to the project directory
+	Old  []byte // nil if the file is new
+	New  []byte // nil if the file is removed
+
+	base []byte // content rendered by the templates, relicensed; nil if unknown
+}
+
+// Relicensing has the changes to relicense a project, to be reviewed before of
+// applying them.
+type Relicensing struct {
+	License string // new license, in lower case
+	Changes []RelicenseChange
+	Skipped []string // files with the header of another license; not changed
+	Missing []string // files without license header
+
+	dir   string
+	bases map[string]string // content rendered by the templates, by file name
+}
+
+// Relicense returns the changes to put the project in "dir" under the license
+// "license", without applying them:
+//
+//   - the license header of the source files is replaced by the new one, when
+//     it is one of the headers rendered by the templates; the years are kept
+//   - the files with the text of the old licenses are replaced
+//   - the section "License" of the readme file is rendered again
+//   - the license expression of REUSE.toml is updated
+func Relicense(dir, license string) (*Relicensing, error) {
+	license, err := checkLicense(license)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := projectConf(dir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.License == license {
+		return nil, fmt.Errorf("%s: project already under license %q", dir, license)
+	}
+
+	op, err := NewProject(cfg)
+	if err != nil {
+		return nil, err
+	}
+	op.parseLicense()
+	op.parseProject()
+
+	newCfg := *cfg
+	newCfg.License = license
+	newCfg.GNUextra = ""
+	newCfg.setLicenseNames()
+
+	np, err := NewProject(&newCfg)
+	if err != nil {
+		return nil, err
+	}
+	np.parseLicense()
+	np.parseProject()
+
+	r := &Relicensing{License: license, dir: dir}
+	if _, err = os.Stat(filepath.Join(dir, _MANIFEST)); err == nil {
+		m, err := readManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+		r.bases = m.Files
+	}
+
+	if err = r.headers(op, np); err != nil {
+		return nil, err
+	}
+	if err = r.licenseFiles(op, np); err != nil {
+		return nil, err
+	}
+	if err = r.readme(np); err != nil {
+		return nil, err
+	}
+
+	// == REUSE.toml
+
+	if cfg.Reuse {
+		data, err := os.ReadFile(filepath.Join(dir, _REUSE_TOML))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			key := "SPDX-License-Identifier = "
+			relicense := func(data []byte) []byte {
+				return bytes.ReplaceAll(data, []byte(key+strconv.Quote(cfg.SPDX)),
+					[]byte(key+strconv.Quote(newCfg.SPDX)))
+			}
+			r.add(_REUSE_TOML, data, relicense(data), relicense)
+		}
+	}
+
+	sort.Slice(r.Changes, func(i, j int) bool { return r.Changes[i].File < r.Changes[j].File })
+	return r, nil
+}
+
+// add adds the change of the file "name", if its content changes.
+// The function relicense does the same change in the content rendered by the
+// templates, when the file is tracked in the manifest; if it is nil, the new
+// content is the rendered one.
+func (r *Relicensing) add(name string, before, after []byte, relicense func([]byte) []byte) {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		return
+	}
+
+	change := RelicenseChange{File: name, Old: before, New: after}
+	if base, ok := r.bases[name]; ok && after != nil {
+		if relicense != nil {
+			change.base = relicense([]byte(base))
+		} else {
+			change.base = after
+		}
+	} else if before == nil {
+		change.base = after
+	}
+	r.Changes = append(r.Changes, change)
+}
+
+// headers replaces the license headers rendered with the project "op" by the
+// ones of "np". The headers are recognized comparing them with the ones of
+// every license.
+func (r *Relicensing) headers(op, np *project) error {
+	matcher, err := newHeaderMatcher(op)
+	if err != nil {
+		return err
+	}
+	year := np.cfg.Year
+	defer func() { np.cfg.Year, np.cfg.LastYear = year, 0 }()
+
+	// replace replaces the header of data, if it is one of the templates.
+	replace := func(data []byte, style CommentStyle) (out []byte, block string, err error) {
+		lines, start, end := findHeader(data, style)
+		block = strings.Join(lines[start:end], "")
+		if !matcher.match(block) {
+			return data, block, nil
+		}
+
+		np.cfg.Year, np.cfg.LastYear = year, 0
+		if first, last, ok := copyrightYears(block); ok {
+			np.cfg.Year, np.cfg.LastYear = first, last
+		}
+		header, err := np.renderHeader(style)
+		if err != nil {
+			return nil, block, err
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(strings.Join(lines[:start], ""))
+		buf.Write(header)
+		buf.WriteString(strings.Join(lines[end:], ""))
+		return buf.Bytes(), block, nil
+	}
+
+	return walkSources(r.dir, op.cfg.attributes().generated, func(name, rel string, data []byte, style CommentStyle) error {
+		out, block, err := replace(data, style)
+		if err != nil {
+			return err
+		}
+		if !reHeaderMark.MatchString(block) {
+			r.Missing = append(r.Missing, rel)
+			return nil
+		}
+		if !matcher.match(block) {
+			r.Skipped = append(r.Skipped, rel)
+			return nil
+		}
+
+		r.add(rel, data, out, func(base []byte) []byte {
+			if out, _, err := replace(base, style); err == nil {
+				return out
+			}
+			return base
+		})
+		return nil
+	})
+}
+
+// licenseFiles replaces the files with the text of the licenses of "op" by the
+// ones of "np". When a single license file, not named by its license, is
+// replaced by another one, its name is kept.
+func (r *Relicensing) licenseFiles(op, np *project) error {
+	corpus, err := loadLicenseCorpus(op.dataDir)
+	if err != nil {
+		return err
+	}
+	files, err := np.renderLicenses()
+	if err != nil {
+		return err
+	}
+	rendered := make(map[string]bool)
+	for _, f := range files {
+		rendered[filepath.ToSlash(f.name)] = true
+	}
+
+	// == Old files
+
+	removed := make([]RelicenseChange, 0)
+
+	list, err := licenseFiles(r.dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range op.cfg.Licenses() {
+		if op.cfg.Reuse {
+			list = append(list, filepath.Join(r.dir, _REUSE_LICENSES, licenseSPDX[v]+".txt"))
+		}
+	}
+	for _, v := range list {
+		rel, _ := filepath.Rel(r.dir, v)
+		rel = filepath.ToSlash(rel)
+		if rendered[rel] {
+			continue
+		}
+
+		data, err := os.ReadFile(v)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		license := ""
+		if path.Dir(rel) == _REUSE_LICENSES {
+			license, _ = licenseBySPDX(strings.TrimSuffix(path.Base(rel), ".txt"))
+		} else if name, _ := corpus.classify(string(data)); name != "" {
+			license = strings.ToLower(name)
+		}
+		if containsString(op.cfg.Licenses(), license) && !containsString(np.cfg.Licenses(), license) {
+			removed = append(removed, RelicenseChange{File: rel, Old: data})
+		}
+	}
+
+	// == New files
+
+	added := make([]RelicenseChange, 0)
+
+	for _, f := range files {
+		name := filepath.ToSlash(f.name)
+
+		data, err := os.ReadFile(filepath.Join(r.dir, f.name))
+		switch {
+		case os.IsNotExist(err):
+			added = append(added, RelicenseChange{File: name, New: f.data, base: f.data})
+		case err != nil:
+			return err
+		case name != _NOTICE: // it has the notices of the dependencies
+			r.add(name, data, f.data, nil)
+		}
+	}
+
+	// A file like "LICENSE" or "COPYING", not named by the license.
+	if len(removed) == 1 && len(added) == 1 && path.Dir(removed[0].File) == "." &&
+		!strings.HasPrefix(removed[0].File, "LICENSE-") && added[0].File != _NOTICE {
+		r.add(removed[0].File, removed[0].Old, added[0].New, nil)
+		return nil
+	}
+	r.Changes = append(r.Changes, removed...)
+	r.Changes = append(r.Changes, added...)
+	return nil
+}
+
+// readme replaces the section "License" of the readme file, in Markdown or in
+// AsciiDoc, by the one rendered by "np".
+func (r *Relicensing) readme(np *project) error {
+	for _, name := range []string{_README, _README_ADOC} {
+		data, err := os.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if _, _, found := readmeSection(data, "License"); !found {
+			continue
+		}
+
+		np.cfg.Readme = "markdown"
+		if name == _README_ADOC {
+			np.cfg.Readme = "asciidoc"
+		}
+		np.parseReadme()
+
+		rendered, err := np.renderSource(name, "Readme")
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		var section []byte
+		if s, e, ok := readmeSection(rendered, "License"); ok {
+			section = rendered[s:e]
+		}
+
+		relicense := func(data []byte) []byte {
+			start, end, found := readmeSection(data, "License")
+			if !found {
+				return data
+			}
+			out := make([]byte, 0, len(data))
+			out = append(out, data[:start]...)
+			out = append(out, section...)
+			return append(out, data[end:]...)
+		}
+		r.add(name, data, relicense(data), relicense)
+	}
+	return nil
+}
+
+// readmeSection returns the range of the section with the heading "title", in
+// Markdown or in AsciiDoc, until the next heading of the same level or higher,
+// or a horizontal rule.
+func readmeSection(data []byte, title string) (start, end int, found bool) {
+	level := 0
+
+	pos := 0
+	for _, line := range splitLines(data) {
+		text := strings.TrimSpace(line)
+		n, heading := headingLevel(text)
+
+		if level == 0 {
+			if n != 0 && heading == title {
+				level, start = n, pos
+			}
+		} else if (n != 0 && n <= level) ||
+			text == "* * *" || text == "***" || text == "---" || text == "'''" {
+			return start, pos, true
+		}
+		pos += len(line)
+	}
+	if level != 0 {
+		return start, len(data), true
+	}
+	return 0, 0, false
+}
+
+// headingLevel returns the level of a heading of Markdown ("#") or AsciiDoc
+// ("="), and its title. The level is 0 if the line is not a heading.
+func headingLevel(line string) (level int, title string) {
+	if line == "" || (line[0] != '#' && line[0] != '=') {
+		return 0, ""
+	}
+	mark := line[0]
+
+	for level < len(line) && line[level] == mark {
+		level++
+	}
+	if level == len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(line[level:])
+}
+
+// Diff returns the changes in unified format.
+func (r *Relicensing) Diff() []byte {
+	var buf bytes.Buffer
+	for _, v := range r.Changes {
+		buf.Write(unifiedDiff(v.File, v.Old, v.New))
+	}
+	return buf.Bytes()
+}
+
+// Apply writes the changes, and records the new license in the manifest, if
+// the project was created by Gowizard.
+func (r *Relicensing) Apply() error {
+	for _, v := range r.Changes {
+		name := filepath.Join(r.dir, filepath.FromSlash(v.File))
+
+		if v.New == nil {
+			if err := os.Remove(name); err != nil {
+				return fmt.Errorf("file error: %s", err)
+			}
+			continue
+		}
+		if err := writeFile(name, v.New); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(r.dir, _MANIFEST)); os.IsNotExist(err) {
+		return nil
+	}
+	m, err := readManifest(r.dir)
+	if err != nil {
+		return err
+	}
+	// The content rendered by the templates is relicensed too, to be the base
+	// of the next upgrade.
+	for _, v := range r.Changes {
+		switch {
+		case v.New == nil:
+			delete(m.Files, v.File)
+		case v.base != nil:
+			m.Files[v.File] = string(v.base)
+		}
+	}
+	m.Conf.License = r.License
+	m.Conf.GNUextra = ""
+	m.Conf.setLicenseNames()
+	return m.write(r.dir)
+}
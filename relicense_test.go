@@ -0,0 +1,129 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRelicenseManifest(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	// A source file edited by the user.
+	source := filepath.Join(dir, p.cfg.Program+".go")
+	data, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := string(data) + "\n// Edited by the user.\n"
+	writeTestFile(t, source, edited)
+
+	r, err := Relicense(dir, "apache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bases are the files rendered with the new license.
+	m, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	np, err := NewProject(m.Conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	np.parseLicense()
+	np.parseProject()
+	files, err := np.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		name := filepath.ToSlash(f.name)
+		want := string(f.data)
+		if copiedLicense(name) {
+			want = fileHash(f.data)
+		}
+		if base, ok := m.Files[name]; ok && base != want {
+			t.Errorf("%s: base not relicensed:\n%s", name, base)
+		}
+	}
+
+	changes, err := Upgrade(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range changes {
+		if v.Action != UpgradeUnchanged {
+			t.Errorf("%s: got action %q", v.File, v.Action)
+		}
+	}
+
+	data, err = os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "\n// Edited by the user.\n") {
+		t.Errorf("edit of the user not kept:\n%s", data)
+	}
+	if !strings.Contains(string(data), "Apache License") {
+		t.Errorf("header not relicensed:\n%s", data)
+	}
+}
+
+func TestRelicenseShebang(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{License: "gpl"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	script := filepath.Join(dir, "run.sh")
+	writeTestFile(t, script, "#!/bin/sh\n\necho hi\n")
+	if _, err := Headers(dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Relicense(dir, "mpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range append(r.Missing, r.Skipped...) {
+		if v == "run.sh" {
+			t.Fatalf("run.sh not relicensed: missing %v, skipped %v", r.Missing, r.Skipped)
+		}
+	}
+	if err = r.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "#!/bin/sh\n\n# Copyright") ||
+		!strings.HasSuffix(string(data), "\necho hi\n") {
+		t.Errorf("header not replaced after the shebang:\n%s", data)
+	}
+	if !strings.Contains(string(data), "Mozilla Public") || strings.Contains(string(data), "GNU General") {
+		t.Errorf("header not relicensed:\n%s", data)
+	}
+}
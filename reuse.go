@@ -0,0 +1,450 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Files of the REUSE specification, https://reuse.software/spec/
+const (
+	_REUSE_TOML     = "REUSE.toml"
+	_REUSE_DEP5     = ".reuse/dep5" // old format of the annotations
+	_REUSE_LICENSES = "LICENSES"    // texts of the licenses, as "<SPDX>.txt"
+)
+
+// reuseAnnotated are the patterns of the files which can not have a header,
+// annotated always in REUSE.toml.
+var reuseAnnotated = []string{"testdata/**", "**/testdata/**",
+	"**.png", "**.jpg", "**.jpeg", "**.gif", "**.ico", "**.pdf"}
+
+// REUSE-IgnoreStart
+var (
+	reSPDXLicense   = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([\w.:+()\t -]*)`)
+	reSPDXCopyright = regexp.MustCompile(`(?im)^\W*(SPDX-FileCopyrightText:|Copyright\s+(\(c\)\s*|©\s*)?\S|©\s*\S)`)
+
+	// Regions of a file which are not read.
+	reReuseIgnore = regexp.MustCompile(`(?s)REUSE-IgnoreStart.*?(REUSE-IgnoreEnd|$)`)
+
+	// Files which have not to be checked.
+	reReuseSkip = regexp.MustCompile(`^(COPYING|LICEN[CS]E)([-.].*)?$|\.license$|^REUSE\.toml$`)
+)
+
+// REUSE-IgnoreEnd
+
+// renderReuse renders the file REUSE.toml, which annotates the copyright and
+// license of the files without header, and of the testdata and images.
+func (p *project) renderReuse(files []renderedFile) ([]byte, error) {
+	copyright, err := p.renderVar("SPDXCopyright")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", _REUSE_TOML, err)
+	}
+
+	paths := []string{_MANIFEST}
+	for _, f := range files {
+		name := filepath.ToSlash(f.name)
+
+		if !strings.HasPrefix(name, _REUSE_LICENSES+"/") &&
+			!bytes.Contains(f.data, []byte("SPDX-License-Identifier:")) {
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+	paths = append(paths, reuseAnnotated...)
+
+	var buf bytes.Buffer
+	buf.WriteString("version = 1\n\n[[annotations]]\npath = [\n")
+	for _, v := range paths {
+		fmt.Fprintf(&buf, "  %s,\n", strconv.Quote(v))
+	}
+	buf.WriteString("]\nprecedence = \"aggregate\"\n")
+	fmt.Fprintf(&buf, "SPDX-FileCopyrightText = %s\n", strconv.Quote(string(copyright)))
+	fmt.Fprintf(&buf, "SPDX-License-Identifier = %s\n", strconv.Quote(p.cfg.SPDX))
+
+	return buf.Bytes(), nil
+}
+
+// * * *
+
+// ReuseLint is the result of checking a tree against the REUSE specification.
+type ReuseLint struct {
+	Files           int      // number of files checked
+	MissingInfo     []string // files without copyright or license
+	MissingLicenses []string // licenses used whose text is not in "LICENSES"
+	UnusedLicenses  []string // texts of licenses which are not used
+}
+
+// Compliant reports whether the tree follows the REUSE specification.
+func (r *ReuseLint) Compliant() bool {
+	return len(r.MissingInfo) == 0 && len(r.MissingLicenses) == 0 && len(r.UnusedLicenses) == 0
+}
+
+// reuseAnnotation is an annotation of REUSE.toml or ".reuse/dep5".
+type reuseAnnotation struct {
+	paths     []*regexp.Regexp
+	copyright bool
+	license   string
+}
+
+// LintReuse checks, without network access, that every file of the tree in
+// "dir" has its copyright and license, in its header, in a file ".license"
+// beside it, or annotated in REUSE.toml or ".reuse/dep5"; and that the text
+// of every license used is in the directory "LICENSES".
+// With Git, the files ignored are not checked.
+func LintReuse(dir string) (*ReuseLint, error) {
+	annotations, err := readReuseAnnotations(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := reuseFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(ReuseLint)
+	used := make(map[string]bool)
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		res.Files++
+
+		if data2, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)+".license")); err == nil {
+			data = data2
+		}
+		data = reReuseIgnore.ReplaceAll(data, nil)
+
+		hasCopyright := reSPDXCopyright.Match(data)
+		licenses := make([]string, 0)
+		for _, m := range reSPDXLicense.FindAllSubmatch(data, -1) {
+			licenses = append(licenses, trimCommentEnd(string(m[1])))
+		}
+
+		for _, a := range annotations {
+			if !a.match(name) {
+				continue
+			}
+			hasCopyright = hasCopyright || a.copyright
+			if a.license != "" {
+				licenses = append(licenses, a.license)
+			}
+		}
+
+		if !hasCopyright || len(licenses) == 0 {
+			res.MissingInfo = append(res.MissingInfo, name)
+		}
+		for _, v := range licenses {
+			for _, id := range spdxIdentifiers(v) {
+				used[id] = true
+			}
+		}
+	}
+
+	// == Texts of licenses
+
+	present := make(map[string]bool)
+	entries, err := os.ReadDir(filepath.Join(dir, _REUSE_LICENSES))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, v := range entries {
+		if !v.IsDir() {
+			present[strings.TrimSuffix(v.Name(), path.Ext(v.Name()))] = true
+		}
+	}
+
+	for id := range used {
+		if !present[id] {
+			res.MissingLicenses = append(res.MissingLicenses, id)
+		}
+	}
+	for id := range present {
+		if !used[id] {
+			res.UnusedLicenses = append(res.UnusedLicenses, id)
+		}
+	}
+	sort.Strings(res.MissingInfo)
+	sort.Strings(res.MissingLicenses)
+	sort.Strings(res.UnusedLicenses)
+
+	return res, nil
+}
+
+// match reports whether the file "name" is covered by the annotation.
+func (a reuseAnnotation) match(name string) bool {
+	for _, re := range a.paths {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reuseFiles returns the files to check of the tree in "dir", with slashes.
+func reuseFiles(dir string) ([]string, error) {
+	list := make([]string, 0)
+
+	if DetectVCS(dir) == "git" {
+		cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git ls-files: %s", err)
+		}
+
+		for _, name := range strings.Split(string(out), "\x00") {
+			if name == "" || skipReuse(name) {
+				continue
+			}
+			// Files removed in the working tree, or symbolic links.
+			if fi, err := os.Lstat(filepath.Join(dir, name)); err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		return list, nil
+	}
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, name)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".hg", ".jj", ".svn":
+				return filepath.SkipDir
+			}
+			if rel == _REUSE_LICENSES || rel == ".reuse" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// The checkout of Fossil.
+		if d.Name() == ".fslckout" || d.Name() == "_FOSSIL_" {
+			return nil
+		}
+		if d.Type().IsRegular() && !skipReuse(rel) {
+			list = append(list, rel)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// skipReuse reports whether the file "name" is not checked.
+func skipReuse(name string) bool {
+	return strings.HasPrefix(name, _REUSE_LICENSES+"/") || strings.HasPrefix(name, ".reuse/") ||
+		reReuseSkip.MatchString(path.Base(name))
+}
+
+// trimCommentEnd removes the end of a block comment, and the spaces.
+func trimCommentEnd(s string) string {
+	s = strings.TrimSpace(s)
+	for _, v := range []string{"*/", "-->"} {
+		s = strings.TrimSpace(strings.TrimSuffix(s, v))
+	}
+	return s
+}
+
+// spdxIdentifiers returns the identifiers of licenses and exceptions of a
+// license expression of SPDX, like "MPL-2.0 OR (MIT AND Apache-2.0)".
+func spdxIdentifiers(expr string) []string {
+	ids := make([]string, 0)
+
+	for _, v := range strings.FieldsFunc(expr, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '(' || r == ')'
+	}) {
+		switch strings.ToUpper(v) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(v, "+"))
+	}
+	return ids
+}
+
+// * * *
+
+// readReuseAnnotations reads the annotations of REUSE.toml and ".reuse/dep5",
+// if any.
+func readReuseAnnotations(dir string) ([]reuseAnnotation, error) {
+	annotations := make([]reuseAnnotation, 0)
+
+	data, err := os.ReadFile(filepath.Join(dir, _REUSE_TOML))
+	if err == nil {
+		list, err := parseReuseTOML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", _REUSE_TOML, err)
+		}
+		annotations = append(annotations, list...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(_REUSE_DEP5)))
+	if err == nil {
+		annotations = append(annotations, parseDep5(data)...)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+var (
+	reTOMLString = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|'([^']*)'`)
+	reParagraph  = regexp.MustCompile(`\n\s*\n`)
+)
+
+// parseReuseTOML parses the tables "annotations" of REUSE.toml. It is only
+// supported the subset of TOML used by that file.
+func parseReuseTOML(data []byte) ([]reuseAnnotation, error) {
+	list := make([]reuseAnnotation, 0)
+	var current *reuseAnnotation
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			current = nil
+			if line == "[[annotations]]" {
+				list = append(list, reuseAnnotation{})
+				current = &list[len(list)-1]
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: invalid syntax", i+1)
+		}
+		key, value = strings.Trim(strings.TrimSpace(key), `"`), strings.TrimSpace(value)
+
+		// Arrays in several lines.
+		if strings.HasPrefix(value, "[") {
+			for !strings.Contains(value, "]") && i+1 < len(lines) {
+				i++
+				value += " " + strings.TrimSpace(lines[i])
+			}
+		}
+		if current == nil {
+			continue // i.e. the version
+		}
+
+		values := make([]string, 0)
+		for _, m := range reTOMLString.FindAllStringSubmatch(value, -1) {
+			if m[2] != "" || strings.HasPrefix(m[0], "'") {
+				values = append(values, m[2])
+				continue
+			}
+			s, err := strconv.Unquote(m[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			values = append(values, s)
+		}
+
+		switch key {
+		case "path":
+			for _, v := range values {
+				current.paths = append(current.paths, globRegexp(v, false))
+			}
+		case "SPDX-FileCopyrightText":
+			current.copyright = len(values) != 0
+		case "SPDX-License-Identifier":
+			current.license = strings.Join(values, " AND ")
+		}
+	}
+	return list, nil
+}
+
+// parseDep5 parses the paragraphs of a file in the format of Debian, which
+// have the fields "Files", "Copyright" and "License".
+func parseDep5(data []byte) []reuseAnnotation {
+	list := make([]reuseAnnotation, 0)
+
+	for _, para := range reParagraph.Split(string(data), -1) {
+		fields := make(map[string]string)
+		key := ""
+
+		for _, line := range strings.Split(para, "\n") {
+			if line == "" {
+				continue
+			}
+			if (line[0] == ' ' || line[0] == '\t') && key != "" {
+				fields[key] += " " + strings.TrimSpace(line)
+				continue
+			}
+			k, v, _ := strings.Cut(line, ":")
+			key = strings.TrimSpace(k)
+			fields[key] = strings.TrimSpace(v)
+		}
+
+		files, ok := fields["Files"]
+		if !ok {
+			continue
+		}
+		a := reuseAnnotation{
+			copyright: fields["Copyright"] != "",
+			license:   fields["License"],
+		}
+		for _, v := range strings.Fields(files) {
+			a.paths = append(a.paths, globRegexp(v, true))
+		}
+		list = append(list, a)
+	}
+	return list
+}
+
+// globRegexp converts a pattern to a regular expression. In REUSE.toml, "*"
+// does not match "/" but "**" does; in Debian, "*" matches any character.
+func globRegexp(pattern string, debian bool) *regexp.Regexp {
+	var buf strings.Builder
+	buf.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			buf.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '*' && debian:
+			buf.WriteString(".*")
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			buf.WriteString(".*")
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?' && debian:
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.MustCompile(buf.String())
+}
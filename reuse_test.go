@@ -0,0 +1,131 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// REUSE-IgnoreStart
+
+const (
+	testMPLHeader    = "// SPDX-FileCopyrightText: 2020 Jane Doe\n//\n// SPDX-License-Identifier: MPL-2.0\n\npackage main\n"
+	testApacheHeader = "// SPDX-FileCopyrightText: 2020 Jane Doe\n//\n// SPDX-License-Identifier: Apache-2.0\n\npackage main\n"
+)
+
+func TestLintReuse(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		lint  ReuseLint
+	}{
+		{"compliant", map[string]string{
+			"main.go":              testMPLHeader,
+			"empty.go":             "",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 1}},
+
+		{"missing license", map[string]string{
+			"main.go":              testMPLHeader,
+			"copyright.go":         "// Copyright 2020 Jane Doe\n\npackage main\n",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 2, MissingInfo: []string{"copyright.go"}}},
+
+		{"missing copyright", map[string]string{
+			"main.go":              "// SPDX-License-Identifier: MPL-2.0\n\npackage main\n",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 1, MissingInfo: []string{"main.go"}}},
+
+		{"missing texts", map[string]string{
+			"main.go":          testMPLHeader,
+			"util.go":          testApacheHeader,
+			"LICENSES/MIT.txt": "text",
+		}, ReuseLint{Files: 2,
+			MissingLicenses: []string{"Apache-2.0", "MPL-2.0"},
+			UnusedLicenses:  []string{"MIT"},
+		}},
+
+		{"expression", map[string]string{
+			"main.go":                 "/* SPDX-FileCopyrightText: 2020 Jane Doe\n   SPDX-License-Identifier: MPL-2.0 OR Apache-2.0 */\n",
+			"LICENSES/MPL-2.0.txt":    "text",
+			"LICENSES/Apache-2.0.txt": "text",
+		}, ReuseLint{Files: 1}},
+
+		{"file .license", map[string]string{
+			"logo.png":             "PNG",
+			"logo.png.license":     "SPDX-FileCopyrightText: 2020 Jane Doe\n\nSPDX-License-Identifier: MPL-2.0\n",
+			"data.bin":             "data",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 2, MissingInfo: []string{"data.bin"}}},
+
+		{"ignored region", map[string]string{
+			"main.go": "// REUSE-IgnoreStart\n// SPDX-FileCopyrightText: 2020 Jane Doe\n" +
+				"// SPDX-License-Identifier: MPL-2.0\n// REUSE-IgnoreEnd\n\npackage main\n",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 1, MissingInfo: []string{"main.go"}, UnusedLicenses: []string{"MPL-2.0"}}},
+
+		{"REUSE.toml", map[string]string{
+			"REUSE.toml": `version = 1
+
+[[annotations]]
+path = [
+  "go.mod",
+  "testdata/**",
+]
+precedence = "aggregate"
+SPDX-FileCopyrightText = "2020 Jane Doe"
+SPDX-License-Identifier = "MPL-2.0"
+
+[[annotations]]
+path = "docs/*.md"
+SPDX-License-Identifier = "CC-BY-4.0"
+`,
+			"go.mod":                 "module example.com/foo\n",
+			"testdata/a/input.txt":   "input",
+			"docs/guide.md":          "SPDX-FileCopyrightText: 2020 Jane Doe\n\nGuide\n",
+			"docs/api/index.md":      "API",
+			"main.go":                testMPLHeader,
+			"LICENSES/MPL-2.0.txt":   "text",
+			"LICENSES/CC-BY-4.0.txt": "text",
+		}, ReuseLint{Files: 5, MissingInfo: []string{"docs/api/index.md"}}},
+
+		{".reuse/dep5", map[string]string{
+			".reuse/dep5": `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: foo
+
+Files: images/*
+Copyright: 2020 Jane Doe
+License: MPL-2.0
+`,
+			"images/logo.svg":      "<svg/>",
+			"LICENSES/MPL-2.0.txt": "text",
+		}, ReuseLint{Files: 1}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, data := range tt.files {
+			writeTestFile(t, filepath.Join(dir, filepath.FromSlash(name)), data)
+		}
+
+		lint, err := LintReuse(dir)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*lint, tt.lint) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *lint, tt.lint)
+		}
+		if lint.Compliant() != reflect.DeepEqual(tt.lint, ReuseLint{Files: tt.lint.Files}) {
+			t.Errorf("%s: got compliant %v", tt.name, lint.Compliant())
+		}
+	}
+}
+
+// REUSE-IgnoreEnd
@@ -7,6 +7,7 @@
 package wizard
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -16,11 +17,11 @@ import (
 
 // Copyright
 const (
-	tmplCopyright = `Copyright {{.Year}} {{.Author}}`
-	tmplCopyleft  = `Written in {{.Year}} by {{.Author}}`
+	tmplCopyright = `Copyright {{.Years}} {{.Address "header"}}`
+	tmplCopyleft  = `Written in {{.Years}} by {{.Address "header"}}`
 
-	tmplOrgCopyright = `Copyright {{.Year}} The {{.Project}} Authors`
-	tmplOrgCopyleft  = `Written in {{.Year}} by the {{.Project}} Authors`
+	tmplOrgCopyright = `Copyright {{.Years}} The {{.Project}} Authors`
+	tmplOrgCopyleft  = `Written in {{.Years}} by the {{.Project}} Authors`
 )
 
 // Licenses
@@ -74,19 +75,48 @@ const (
 {{.Comment}}
 {{.Comment}} You should have received a copy of the CC0 Public Domain Dedication along
 {{.Comment}} with this software. If not, see <http://creativecommons.org/publicdomain/zero/1.0/>.
+`
+
+	tmplDual = `{{.Comment}} {{template "Copyright" .}}
+{{.Comment}}
+{{.Comment}} This program can be used under the terms of any of the next licenses,
+{{.Comment}} at your option:
+{{.Comment}}
+{{range .FullLicenses}}{{$.Comment}}   * {{.}}
+{{end}}{{.Comment}}
+{{.Comment}} The texts of the licenses are in the files "LICENSE-*.txt".
 `
 )
 
-// Base of source files
+// REUSE
 const (
-	tmplGo = `{{template "Header" .}}
-package {{.Program}}
+	tmplSPDXCopyright = `{{.Years}} {{if .Org}}The {{.Project}} Authors{{else}}{{.Address "header"}}{{end}}`
 
-import (
-	
+	tmplSPDX = `{{.Comment}} SPDX-FileCopyrightText: {{template "SPDXCopyright" .}}
+{{.Comment}}
+{{.Comment}} SPDX-License-Identifier: {{.SPDX}}
+`
+
+	// Text of the proprietary license.
+	tmplProprietary = `Copyright {{.Years}} {{if .Org}}{{.Org}}{{else}}{{.Author}}{{end}}. All rights reserved.
+
+This software is proprietary. It can not be used, copied, modified nor
+distributed without the written permission of the copyright holder.
+`
 )
 
+// Lines around the license header, for block comments
+const (
+	tmplCommentStart = `{{with .CommentStart}}{{.}}
+{{end}}`
+	tmplCommentEnd = `{{with .CommentEnd}}{{.}}
+{{end}}`
+)
 
+// Base of source files
+const (
+	tmplGo = `{{template "Header" .}}
+package {{.Program}}
 `
 
 	tmplTest = `{{template "Header" .}}
@@ -95,94 +125,268 @@ package {{.Program}}
 import "testing"
 
 func Test(t *testing.T) {
-	
+	t.Skip("not implemented")
 }
 `
 
 	tmplExample = `{{template "Header" .}}
 package {{.Program}}_test
 
+import "fmt"
+
+func Example() {
+	fmt.Println()
+	// Output:
+}
+`
+)
+
+// Commands and services
+const (
+	tmplCommand = `{{template "Header" .}}
+// Command {{.Program}} << COMMAND SYNOPSIS >>
+package main
+
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
+)
+
+var fVerbose = flag.Bool("v", false, "verbose mode")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: {{.Program}} [flags] [arguments]\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
 
-	"{{.ImportPath}}"
+	if err := run(os.Stdout, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "{{.Program}}: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command with the arguments "args", writing the output to w.
+func run(w io.Writer, args []string) error {
+	if *fVerbose {
+		fmt.Fprintf(w, "arguments: %q\n", args)
+	}
+	return nil
+}
+`
+
+	tmplCommandTest = `{{template "Header" .}}
+package main
+
+import (
+	"bytes"
+	"testing"
 )
 
-func Example() {
-	fmt.Println()
-	// Output:
-	// 
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := run(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+	tmplService = `{{template "Header" .}}
+// Command {{.Program}} runs the {{.Project}} service.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"{{.ImportPath}}/internal/server"
+)
+
+var (
+	fAddr    = flag.String("addr", ":8080", "address to listen on")
+	fTimeout = flag.Duration("timeout", 10*time.Second, "time to wait for the requests at shutdown")
+)
+
+func main() {
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *fAddr, *fTimeout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves HTTP on addr until ctx is done. Then, the server is shut down
+// waiting for the active requests up to timeout.
+func run(ctx context.Context, addr string, timeout time.Duration) error {
+	srv := server.New(addr)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+`
+
+	tmplServiceTest = `{{template "Header" .}}
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := run(ctx, "127.0.0.1:0", time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+	tmplServer = `{{template "Header" .}}
+// Package server implements the HTTP server of {{.Project}}.
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// New returns an HTTP server to listen on addr.
+func New(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// Handler returns the handler with the routes of the service.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
 }
 `
+
+	tmplServerTest = `{{template "Header" .}}
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
 )
 
-// User configuration
-const tmplUserConfig = `
-org: {{.Org}}
-author: {{.Author}}
-email: {{.Email}}
-license: {{.License}}
-vcs: {{.VCS}}
-import: {{.ImportPath}}
+func TestHealth(t *testing.T) {
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
 `
+)
 
-// Ignore file for VCS
-const hgIgnoreTop = "syntax: glob\n"
-
-var tmplIgnore = `## Special files
-*~
-[._]*
-
-# Compiled Object files, Static and Dynamic libs (Shared Objects)
-*.[ao]
-*.so
-*.dll
-
-# Folders
-_obj
-_test
-
-# Architecture specific extensions/prefixes
-*.[568vq]
-[568vq].out
-
-*.cgo?.*
-
-_testmain.go
-
-# Compiled Go source
-*.exe
-*.test
-*.prof
-
-## Data
-*.bin
-
-## Packages
-# It's better to unpack these files and commit the raw source since
-# git has its own built in compression methods
-*.7z
-*.dmg
-*.gz
-*.iso
-*.jar
-*.rar
-*.tar
-*.zip
-
-## Logs and databases
-*.db
-*.log
-*.sql
-*.sqlite
-
-## OS generated files
-Icon?
-
-# * * *
-{{.Program}}
+// Modules and workspaces
+const (
+	tmplGoMod = `{{if .EmailFor "gomod"}}// Maintainer: {{.Address "gomod"}}
+
+{{end}}module {{.ModulePath}}
+
+go {{.GoVersion}}
 `
 
+	tmplGoWork = `go {{.GoVersion}}
+
+use (
+{{range .Modules}}	./{{.}}
+{{end}})
+`
+)
+
+// Continuous integration
+const (
+	tmplCIGitHub = `name: CI
+
+on:
+  push:
+  pull_request:
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: {{if .Modules}}go.work{{else}}go.mod{{end}}
+      - run: go vet {{.TestPackages}}
+      - run: go test -coverprofile=coverage.out {{.TestPackages}}
+      - uses: codecov/codecov-action@v4
+        with:
+          files: coverage.out
+          token: ${{"{{"}} secrets.CODECOV_TOKEN }}
+`
+
+	tmplCIGitLab = `image: "golang:{{.GoVersion}}"
+
+test:
+  script:
+    - go vet {{.TestPackages}}
+    - go test -coverprofile=coverage.out {{.TestPackages}}
+    - go tool cover -func=coverage.out
+  coverage: '/total:\s+\(statements\)\s+\d+\.\d+%/'
+`
+)
+
 // Information files
 const (
 	tmplAuthors = `
@@ -198,8 +402,8 @@ Names should be added to this file as:
 Please keep the list sorted.
 * * *
 
-{{with .Org}}{{.}}{{else}}{{.Email}}{{end}}
-
+{{range .AuthorLines}}{{.}}
+{{end}}
 `
 
 	tmplContributors = `
@@ -217,33 +421,78 @@ Names should be added to this file as:
 Please keep the list sorted.
 * * *
 
-{{.Email}}
-
+{{range .ContributorLines}}{{.}}
+{{end}}
 `
 
-	tmplChangelog = `
-This file documents the changes in **{{.Project}}** versions that are listed below.
+	tmplChangelog = `# Changelog
 
-Items should be added to this file as:
+All notable changes to **{{.Project}}** are documented in this file.
 
-	#### YYYY-MM-DD Release
-	One change.
-	Other change.
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
 
-* * *
+## [Unreleased]
+
+### Added
+
+### Changed
+
+### Deprecated
 
+### Removed
 
+### Fixed
+
+### Security
+`
+
+	tmplNotice = `{{.Project}}
+{{template "Copyright" .}}
 `
 
 	tmplReadme = `{{.Project}}
 {{.ProjectHeader}}
+{{with .Badges}}
+{{range .}}[![{{.Name}}]({{.Image}})]({{.Link}})
+{{end}}{{end}}
 << PROJECT SYNOPSIS >>
 
-[Documentation online](http://godoc.org/{{with .ImportPath}}{{.}}{{else}}<< IMPORT PATH >>{{end}})
+[Documentation online](https://pkg.go.dev/{{.DocPath}})
 
 ## Installation
+{{with .Packages}}
+{{range .}}	go get {{.}}
+{{end}}{{end}}{{with .Commands}}{{if $.Packages}}
+To install the command:
+{{end}}
+{{range .}}	go install {{.}}@latest
+{{end}}{{end}}
+## Usage
+{{with .Packages}}
+{{range .}}	import "{{.}}"
+{{end}}
+See the examples in the documentation.
+{{end}}{{if eq .Kind "service"}}
+	{{.Program}} [-addr :8080] [-timeout 10s]
+
+It serves HTTP on the address of the flag -addr. At receiving SIGINT or SIGTERM,
+the server is shut down waiting for the active requests up to the flag -timeout.
+{{else if .Commands}}{{if .Packages}}
+The command:
+{{end}}
+	{{.Program}} [flags] [arguments]
+
+Run "{{.Program}} -h" to list the flags.
+{{end}}
+## Contributing
 
-	go get {{with .ImportPath}}{{.}}{{else}}<< IMPORT PATH >>{{end}}
+The changes are documented in the section "Unreleased" of [CHANGELOG.md](CHANGELOG.md).
+Before of sending them, run the tests:
+
+	go test {{.TestPackages}}
+
+The contributors are listed in [CONTRIBUTORS.txt.md](CONTRIBUTORS.txt.md).
 {{if .FullLicense}}
 ## License
 
@@ -253,11 +502,88 @@ Unless otherwise noted:
 {{end}}
 * * *
 *Generated by [Gowizard](https://github.com/tredoe/wizard)*
+`
+
+	tmplReadmeAdoc = `= {{.Project}}
+{{with .Badges}}
+{{range .}}image:{{.Image}}[{{.Name}},link={{.Link}}]
+{{end}}{{end}}
+<< PROJECT SYNOPSIS >>
+
+https://pkg.go.dev/{{.DocPath}}[Documentation online]
+
+== Installation
+{{with .Packages}}
+[source,sh]
+----
+{{range .}}go get {{.}}
+{{end}}----
+{{end}}{{with .Commands}}{{if $.Packages}}
+To install the command:
+{{end}}
+[source,sh]
+----
+{{range .}}go install {{.}}@latest
+{{end}}----
+{{end}}
+== Usage
+{{with .Packages}}
+[source,go]
+----
+{{range .}}import "{{.}}"
+{{end}}----
+
+See the examples in the documentation.
+{{end}}{{if eq .Kind "service"}}
+[source,sh]
+----
+{{.Program}} [-addr :8080] [-timeout 10s]
+----
+
+It serves HTTP on the address of the flag -addr. At receiving SIGINT or SIGTERM,
+the server is shut down waiting for the active requests up to the flag -timeout.
+{{else if .Commands}}{{if .Packages}}
+The command:
+{{end}}
+[source,sh]
+----
+{{.Program}} [flags] [arguments]
+----
+
+Run "{{.Program}} -h" to list the flags.
+{{end}}
+== Contributing
+
+The changes are documented in the section "Unreleased" of link:CHANGELOG.md[].
+Before of sending them, run the tests:
+
+[source,sh]
+----
+go test {{.TestPackages}}
+----
+
+The contributors are listed in link:CONTRIBUTORS.txt.md[].
+{{if .FullLicense}}
+== License
+
+Unless otherwise noted:
+
+* The source files are distributed under the _{{.FullLicense}}_
+{{end}}
+'''
+
+_Generated by https://github.com/tredoe/wizard[Gowizard]_
 `
 )
 
 // * * *
 
+// renderHeader renders the license header commented with the style given.
+func (p *project) renderHeader(style CommentStyle) ([]byte, error) {
+	p.cfg.setCommentStyle(style)
+	return p.renderVar("Header")
+}
+
 // parseFromFile renders the template "src", creating a file in "dst".
 func (p *project) parseFromFile(dst, src string) error {
 	file, err := createFile(dst)
@@ -289,14 +615,46 @@ func (p *project) parseFromVar(dst string, tmplName string) error {
 	return nil
 }
 
-// parseLicense parses the license header.
-// charComment is the character used to comment in code files.
-func (p *project) parseLicense(charComment string) {
+// renderVar renders the template "tmplName" in memory.
+func (p *project) renderVar(tmplName string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := p.tmpl.ExecuteTemplate(&buf, tmplName, p.cfg); err != nil {
+		return nil, fmt.Errorf("execution failed: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSource renders the template "tmplName" for the file "name", whose
+// license header is commented with the style of the file.
+// The Go files are formatted.
+func (p *project) renderSource(name, tmplName string) ([]byte, error) {
+	if style, ok := CommentStyleFor(name, nil); ok {
+		p.cfg.setCommentStyle(style)
+	}
+	data, err := p.renderVar(tmplName)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(name, ".go") {
+		return formatGo(tmplName, p.cfg.ModulePath(), data)
+	}
+	return data, nil
+}
+
+// parseLicense parses the license header. It is rendered with the comment
+// style set in the configuration.
+func (p *project) parseLicense() {
 	licenseName := strings.Split(p.cfg.License, "-")[0]
+	if len(p.cfg.Licenses()) > 1 {
+		licenseName = "dual"
+	}
 	tmplHeader := ""
 
-	p.cfg.Comment = charComment
-	p.cfg.Year = time.Now().Year()
+	p.cfg.setCommentStyle(ListCommentStyle[_COMMENT_STYLE])
+	if p.cfg.Year == 0 {
+		p.cfg.Year = time.Now().Year()
+	}
 
 	switch licenseName {
 	case "mpl":
@@ -311,11 +669,17 @@ func (p *project) parseLicense(charComment string) {
 		if licenseName == "agpl" {
 			p.cfg.GNUextra = "Affero"
 		}
+	case "dual":
+		tmplHeader = tmplDual
 	case "none":
 		tmplHeader = tmplNone
 	}
+	if p.cfg.Reuse {
+		tmplHeader = tmplSPDX
+	}
 
-	p.tmpl = template.Must(p.tmpl.New("Header").Parse(tmplHeader))
+	p.tmpl = template.Must(p.tmpl.New("Header").Parse(tmplCommentStart + tmplHeader + tmplCommentEnd))
+	p.tmpl = template.Must(p.tmpl.New("SPDXCopyright").Parse(tmplSPDXCopyright))
 
 	if licenseName != "cc0" {
 		if p.cfg.Org == "" {
@@ -332,19 +696,36 @@ func (p *project) parseLicense(charComment string) {
 	}
 }
 
+// parseReadme parses the template of the readme file, by its format.
+func (p *project) parseReadme() {
+	if p.cfg.Readme == "asciidoc" {
+		p.tmpl = template.Must(p.tmpl.New("Readme").Parse(tmplReadmeAdoc))
+	} else {
+		p.tmpl = template.Must(p.tmpl.New("Readme").Parse(tmplReadme))
+	}
+}
+
 // parseProject parses the templates for the project.
 func (p *project) parseProject() {
 	p.tmpl = template.Must(p.tmpl.New("Authors").Parse(tmplAuthors))
 	p.tmpl = template.Must(p.tmpl.New("Contributors").Parse(tmplContributors))
 	p.tmpl = template.Must(p.tmpl.New("Changelog").Parse(tmplChangelog))
-	p.tmpl = template.Must(p.tmpl.New("Readme").Parse(tmplReadme))
+	p.tmpl = template.Must(p.tmpl.New("Notice").Parse(tmplNotice))
+	p.tmpl = template.Must(p.tmpl.New("Proprietary").Parse(tmplProprietary))
+	p.parseReadme()
 	p.tmpl = template.Must(p.tmpl.New("Go").Parse(tmplGo))
 	p.tmpl = template.Must(p.tmpl.New("Test").Parse(tmplTest))
 	p.tmpl = template.Must(p.tmpl.New("Example").Parse(tmplExample))
-
-	// == Ignore file
-	if p.cfg.VCS == "hg" {
-		tmplIgnore = hgIgnoreTop + tmplIgnore
-	}
-	p.tmpl = template.Must(p.tmpl.New("Ignore").Parse(tmplIgnore))
+	p.tmpl = template.Must(p.tmpl.New("Command").Parse(tmplCommand))
+	p.tmpl = template.Must(p.tmpl.New("CommandTest").Parse(tmplCommandTest))
+	p.tmpl = template.Must(p.tmpl.New("Service").Parse(tmplService))
+	p.tmpl = template.Must(p.tmpl.New("ServiceTest").Parse(tmplServiceTest))
+	p.tmpl = template.Must(p.tmpl.New("Server").Parse(tmplServer))
+	p.tmpl = template.Must(p.tmpl.New("ServerTest").Parse(tmplServerTest))
+	p.tmpl = template.Must(p.tmpl.New("GoMod").Parse(tmplGoMod))
+	p.tmpl = template.Must(p.tmpl.New("GoWork").Parse(tmplGoWork))
+	p.tmpl = template.Must(p.tmpl.New("CIGitHub").Parse(tmplCIGitHub))
+	p.tmpl = template.Must(p.tmpl.New("CIGitLab").Parse(tmplCIGitLab))
+
+	p.parseIgnore()
 }
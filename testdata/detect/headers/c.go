@@ -0,0 +1,5 @@
+// SPDX-FileCopyrightText: 2020 Jane Doe
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
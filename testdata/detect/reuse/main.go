@@ -0,0 +1,7 @@
+// SPDX-FileCopyrightText: 2020 Jane Doe <jane@example.com>
+//
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+func main() {}
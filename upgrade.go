@@ -0,0 +1,234 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// _MANIFEST is the file where is recorded how the project was generated.
+const _MANIFEST = ".gowizard-manifest.json"
+
+// _HASH_PREFIX starts the files stored in the manifest by their hash.
+const _HASH_PREFIX = "sha256:"
+
+// manifest records the configuration used to create a project, and the files
+// rendered at that time, so that the project can be upgraded when the
+// templates change.
+type manifest struct {
+	Conf  *Conf
+	Files map[string]string // rendered content by file name; see copiedLicense
+}
+
+// newManifest returns the manifest of a project created with the
+// configuration cfg.
+func newManifest(cfg *Conf, files []renderedFile) *manifest {
+	m := &manifest{cfg, make(map[string]string)}
+	for _, f := range files {
+		m.Files[filepath.ToSlash(f.name)] = string(f.data)
+	}
+	return m
+}
+
+// write writes the manifest into the project directory "dir".
+// The texts of licenses are written by their hash.
+func (m *manifest) write(dir string) error {
+	out := &manifest{m.Conf, make(map[string]string, len(m.Files))}
+	for name, v := range m.Files {
+		if copiedLicense(name) && !strings.HasPrefix(v, _HASH_PREFIX) {
+			v = fileHash([]byte(v))
+		}
+		out.Files[name] = v
+	}
+
+	data, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		return fmt.Errorf("manifest error: %s", err)
+	}
+	return writeFile(filepath.Join(dir, _MANIFEST), append(data, '\n'))
+}
+
+// readManifest reads the manifest from the project directory "dir".
+func readManifest(dir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, _MANIFEST))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: not created by Gowizard; manifest %s not found",
+				dir, _MANIFEST)
+		}
+		return nil, err
+	}
+
+	m := new(manifest)
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("manifest error: %s", err)
+	}
+	if m.Conf == nil {
+		return nil, fmt.Errorf("manifest error: configuration not found")
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	// The email was stored obfuscated.
+	if strings.Contains(m.Conf.Email, " AT ") {
+		m.Conf.Email = unobfuscateEmail(m.Conf.Email)
+	}
+	return m, nil
+}
+
+// copiedLicense reports whether the file is the text of a license copied from
+// the data directory. It is stored in the manifest by its hash, since it is
+// large and it is not changed by the user.
+func copiedLicense(name string) bool {
+	if path.Dir(name) == _REUSE_LICENSES {
+		// The proprietary license is rendered by a template.
+		return name != path.Join(_REUSE_LICENSES, licenseSPDX["none"]+".txt")
+	}
+	return path.Dir(name) == "." && strings.HasPrefix(name, "LICENSE-") &&
+		strings.HasSuffix(name, ".txt")
+}
+
+// fileHash returns the hash of the content of a file, as it is stored in the
+// manifest.
+func fileHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return _HASH_PREFIX + hex.EncodeToString(sum[:])
+}
+
+// UpgradeAction is the action done on a file during an upgrade.
+type UpgradeAction string
+
+// Upgrade actions
+const (
+	UpgradeUnchanged UpgradeAction = "unchanged" // the templates have not changed
+	UpgradeUpdated   UpgradeAction = "updated"   // not modified by the user
+	UpgradeMerged    UpgradeAction = "merged"    // merged with the user changes
+	UpgradeConflict  UpgradeAction = "conflict"  // written with conflict markers
+	UpgradeAdded     UpgradeAction = "added"     // new in the templates
+	UpgradeSkipped   UpgradeAction = "skipped"   // removed by the user
+	UpgradeRemoved   UpgradeAction = "removed"   // no longer in the templates; kept
+)
+
+// UpgradeChange is the change done on a file of the project.
+type UpgradeChange struct {
+	File   string
+	Action UpgradeAction
+}
+
+// Upgrade re-renders the templates of the project created in "dir", using
+// the configuration recorded in its manifest.
+//
+// Each file is updated doing a three-way merge between the file rendered when
+// it was created, the file rendered by the current templates, and the file
+// from the user. The conflicts are written between markers, like VCSs do.
+// Returns the changes done, sorted by file name.
+func Upgrade(dir string) ([]UpgradeChange, error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	return m.upgrade(dir, nil)
+}
+
+// upgrade upgrades the project in "dir" to the current templates, rendered
+// with the configuration of the manifest. If filter is not nil, only the files
+// for which it returns true are upgraded.
+func (m *manifest) upgrade(dir string, filter func(name string) bool) ([]UpgradeChange, error) {
+	p, err := NewProject(m.Conf)
+	if err != nil {
+		return nil, err
+	}
+	p.parseLicense()
+	p.parseProject()
+
+	files, err := p.render()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]UpgradeChange, 0)
+	seen := make(map[string]bool)
+
+	for _, f := range files {
+		name := filepath.ToSlash(f.name)
+		seen[name] = true
+
+		if filter != nil && !filter(name) {
+			continue
+		}
+		action, err := upgradeFile(filepath.Join(dir, f.name), m.Files[name], f.data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, UpgradeChange{name, action})
+		m.Files[name] = string(f.data)
+	}
+	for name := range m.Files {
+		if !seen[name] && (filter == nil || filter(name)) {
+			changes = append(changes, UpgradeChange{name, UpgradeRemoved})
+			delete(m.Files, name)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].File < changes[j].File })
+
+	if err = m.write(dir); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// upgradeFile updates the file "dst", whose content was rendered as "base",
+// to the new content "next".
+// When the base is a hash, its content is only known if it is the one of the
+// file or the new one; else, it is merged like a file without base.
+func upgradeFile(dst, base string, next []byte) (UpgradeAction, error) {
+	current, err := os.ReadFile(dst)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if base != "" {
+			return UpgradeSkipped, nil
+		}
+		return UpgradeAdded, writeFile(dst, next)
+	}
+
+	if strings.HasPrefix(base, _HASH_PREFIX) {
+		switch base {
+		case fileHash(current):
+			base = string(current)
+		case fileHash(next):
+			base = string(next)
+		default:
+			base = ""
+		}
+	}
+
+	switch {
+	case bytes.Equal(current, next):
+		return UpgradeUnchanged, nil
+	case base == string(next):
+		return UpgradeUnchanged, nil
+	case base == string(current):
+		return UpgradeUpdated, writeFile(dst, next)
+	}
+
+	out, conflict := merge3([]byte(base), current, next)
+	if conflict {
+		return UpgradeConflict, writeFile(dst, out)
+	}
+	return UpgradeMerged, writeFile(dst, out)
+}
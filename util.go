@@ -41,6 +41,18 @@ func createFile(dst string) (*os.File, error) {
 	return file, nil
 }
 
+// writeFile writes data to the file dst, creating its directory if it is
+// necessary.
+func writeFile(dst string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), _DIR_PERM); err != nil {
+		return fmt.Errorf("directory error: %s", err)
+	}
+	if err := os.WriteFile(dst, data, _FILE_PERM); err != nil {
+		return fmt.Errorf("file error: %s", err)
+	}
+	return nil
+}
+
 // getProjectName returns the project name from Readme file.
 // It should be in the first line.
 func getProjectName() (string, error) {
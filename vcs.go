@@ -0,0 +1,572 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	_COMMIT_MSG = "Initial commit" // message of the initial commit, by default
+	_REMOTE     = "origin"         // name of the remote repository
+)
+
+// Syntaxes of the patterns to ignore files.
+const (
+	IgnoreGitSyntax  = "gitignore" // Git: comments, globs and negations
+	IgnoreHgSyntax   = "hgignore"  // Mercurial: comments and globs, after "syntax: glob"
+	IgnoreGlobSyntax = "glob"      // Fossil: globs of paths, where "*" matches "/"
+	IgnoreSvnSyntax  = "svn"       // Subversion: globs of names in the directory
+)
+
+// VCS is a version control system.
+type VCS interface {
+	// Init creates the repository in the directory, which has the project.
+	Init(dir string) error
+
+	// IgnoreFileName returns the path of the file with the patterns to
+	// ignore, relative to the repository root. It is empty when the patterns
+	// are not stored in a file.
+	IgnoreFileName() string
+
+	// IgnoreSyntax returns the syntax of the patterns to ignore.
+	IgnoreSyntax() string
+
+	// Commit records the files, given relative to the directory, with the
+	// message and the author, like "Name <email>". Without author, it is used
+	// the one configured in the VCS.
+	Commit(dir string, files []string, msg, author string) error
+
+	// Detect reports whether the directory is inside of a repository.
+	Detect(dir string) bool
+}
+
+// Optional features of a VCS.
+type (
+	// branchSetter sets the name of the default branch, before of the first
+	// commit.
+	branchSetter interface {
+		SetBranch(dir, name string) error
+	}
+
+	// remoteSetter adds the remote repository given by its URL.
+	remoteSetter interface {
+		SetRemote(dir, url string) error
+	}
+
+	// tagger tags the last commit.
+	tagger interface {
+		Tag(dir, name, msg string) error
+
+		// HasTag reports whether the tag exists.
+		HasTag(dir, name string) (bool, error)
+	}
+
+	// changeLister lists the files changed and not committed, relative to
+	// the directory. The files not tracked are not listed.
+	changeLister interface {
+		Changes(dir string) ([]string, error)
+	}
+
+	// ignoreSetter sets the patterns to ignore when they are not stored in
+	// a file.
+	ignoreSetter interface {
+		SetIgnore(dir string, patterns []byte) error
+	}
+)
+
+// vcsBackends are the available VCSs, by their command name.
+var vcsBackends = map[string]VCS{
+	"fossil": fossilVCS{},
+	"git":    gitVCS{},
+	"hg":     hgVCS{},
+	"jj":     jjVCS{},
+	"svn":    svnVCS{},
+}
+
+// vcsDetectOrder is the order to detect the repositories. Jujutsu goes before
+// Git since it can be colocated with a Git repository.
+var vcsDetectOrder = []string{"jj", "git", "hg", "fossil", "svn"}
+
+// DetectVCS returns the name of the VCS whose repository contains the
+// directory "dir", or an empty string if it is not found.
+func DetectVCS(dir string) string {
+	for _, name := range vcsDetectOrder {
+		if vcsBackends[name].Detect(dir) {
+			return name
+		}
+	}
+	return ""
+}
+
+// RemoteURL returns the URL of the remote repository, replacing "$" by the
+// program name; i.e. "git@github.com:org/$.git".
+// The values "ssh" and "https" build it from the import path.
+func (c *Conf) RemoteURL() (string, error) {
+	switch c.Remote {
+	case "":
+		return "", nil
+	case "ssh", "https":
+	default:
+		return strings.Replace(c.Remote, "$", c.Program, -1), nil
+	}
+
+	if len(c.ImportPaths) == 0 || c.ImportPaths[0] == "" {
+		return "", fmt.Errorf("remote %q requires the import path", c.Remote)
+	}
+	importPath := path.Join(c.ImportPaths[0], c.Program)
+
+	host, repo, found := strings.Cut(importPath, "/")
+	if !found || !strings.Contains(host, ".") {
+		return "", fmt.Errorf("remote %q: no host in import path: %q",
+			c.Remote, importPath)
+	}
+
+	// Git hosting, also used by Jujutsu.
+	isGit := c.VCS == "git" || c.VCS == "jj"
+
+	suffix := ""
+	if isGit {
+		suffix = ".git"
+	}
+	if c.Remote == "https" {
+		return "https://" + importPath + suffix, nil
+	}
+	if isGit {
+		return "git@" + host + ":" + repo + suffix, nil
+	}
+	return "ssh://" + host + "/" + repo, nil
+}
+
+// commitAuthor returns the author of the initial commit, like "Name <email>".
+// It is empty, to use the one configured in the VCS, when the author or the
+// email are not set.
+func (c *Conf) commitAuthor() string {
+	if c.CommitAuthor != "" {
+		return c.CommitAuthor
+	}
+	if c.Author == "" || c.Email == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s <%s>", c.Author, c.Email)
+}
+
+// commitMsg returns the message of the initial commit.
+func (c *Conf) commitMsg() string {
+	if c.CommitMsg != "" {
+		return c.CommitMsg
+	}
+	return _COMMIT_MSG
+}
+
+// checkRepository checks the options of the repository for the VCS.
+func (c *Conf) checkRepository() error {
+	v, ok := vcsBackends[c.VCS]
+	if !ok {
+		return nil
+	}
+
+	if _, ok = v.(branchSetter); !ok && c.Branch != "" {
+		return fmt.Errorf("the branch is not supported for %s", ListVCS[c.VCS])
+	}
+	if _, ok = v.(remoteSetter); !ok && c.Remote != "" {
+		return fmt.Errorf("the remote is not supported for %s", ListVCS[c.VCS])
+	}
+	_, err := c.RemoteURL()
+	return err
+}
+
+// initVCS initializes the repository in the project directory. Then, it sets
+// the attributes of files, the patterns to ignore, the default branch and the
+// remote repository, and commits the files, when they have been configured.
+func (p *project) initVCS(files []string, ignore []byte) error {
+	dir := p.cfg.Program
+
+	v, ok := vcsBackends[p.cfg.VCS]
+	if !ok {
+		return fmt.Errorf("unavailable VCS: %q", p.cfg.VCS)
+	}
+	if err := v.Init(dir); err != nil {
+		return err
+	}
+
+	if s, ok := v.(attributesSetter); ok {
+		if err := s.SetAttributes(dir, p.cfg.attributes()); err != nil {
+			return err
+		}
+	}
+	if s, ok := v.(ignoreSetter); ok && ignore != nil {
+		if err := s.SetIgnore(dir, ignore); err != nil {
+			return err
+		}
+	}
+	if p.cfg.Branch != "" {
+		if err := v.(branchSetter).SetBranch(dir, p.cfg.Branch); err != nil {
+			return err
+		}
+	}
+
+	remote, err := p.cfg.RemoteURL()
+	if err != nil {
+		return err
+	}
+	if remote != "" {
+		if err = v.(remoteSetter).SetRemote(dir, remote); err != nil {
+			return err
+		}
+	}
+
+	if p.cfg.Commit {
+		return v.Commit(dir, files, p.cfg.commitMsg(), p.cfg.commitAuthor())
+	}
+	return nil
+}
+
+// runVCS runs the VCS command in the directory "dir".
+func runVCS(dir, vcs string, args ...string) error {
+	cmd := exec.Command(vcs, args...)
+	cmd.Dir = dir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s: %s", vcs, args[0], cmdError(out, err))
+	}
+	return nil
+}
+
+// outputVCS runs the VCS command in the directory "dir", returning its output
+// by lines.
+func outputVCS(dir, vcs string, args ...string) ([]string, error) {
+	cmd := exec.Command(vcs, args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if e, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s %s: %s", vcs, args[0], cmdError(e.Stderr, err))
+		}
+		return nil, fmt.Errorf("%s %s: %s", vcs, args[0], err)
+	}
+	if out = bytes.TrimSpace(out); len(out) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(out), "\n"), nil
+}
+
+// cmdError returns the output of a command which has failed, or else its
+// error.
+func cmdError(out []byte, err error) string {
+	if out = bytes.TrimSpace(out); len(out) != 0 {
+		return string(out)
+	}
+	return err.Error()
+}
+
+// printInit prints the output of the command which has initialized the
+// repository, with the path relative to the working directory.
+func printInit(out []byte) {
+	out_ := string(out)
+	if wd, err := os.Getwd(); err == nil {
+		out_ = strings.Replace(out_, wd+string(os.PathSeparator), "", 1)
+	}
+
+	fmt.Print(out_)
+}
+
+// appendFile appends the text to the file "name".
+func appendFile(name, text string) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, _FILE_PERM)
+	if err != nil {
+		return fmt.Errorf("file error: %s", err)
+	}
+	if _, err = f.WriteString(text); err != nil {
+		f.Close()
+		return fmt.Errorf("file error: %s", err)
+	}
+	return f.Close()
+}
+
+// siblingRepo returns the path of a repository stored out of the project
+// directory "dir", beside it, with the extension "ext".
+func siblingRepo(dir, ext string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return abs + ext, nil
+}
+
+//
+// == Backends
+
+// gitVCS is Git.
+type gitVCS struct{}
+
+func (gitVCS) Init(dir string) error {
+	out, err := exec.Command("git", "init", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git init: %s", cmdError(out, err))
+	}
+	printInit(out)
+	return nil
+}
+
+func (gitVCS) IgnoreFileName() string { return ".gitignore" }
+func (gitVCS) IgnoreSyntax() string   { return IgnoreGitSyntax }
+
+func (gitVCS) Commit(dir string, files []string, msg, author string) error {
+	// The files are given since they could match the ignore file.
+	if err := runVCS(dir, "git", append([]string{"add", "-f", "--"}, files...)...); err != nil {
+		return err
+	}
+	args := []string{"commit", "-q", "-m", msg}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	return runVCS(dir, "git", args...)
+}
+
+func (gitVCS) Detect(dir string) bool { return findUp(dir, ".git") != "" }
+
+func (gitVCS) SetBranch(dir, name string) error {
+	return runVCS(dir, "git", "symbolic-ref", "HEAD", "refs/heads/"+name)
+}
+
+func (gitVCS) SetRemote(dir, url string) error {
+	return runVCS(dir, "git", "remote", "add", _REMOTE, url)
+}
+
+func (gitVCS) Tag(dir, name, msg string) error {
+	return runVCS(dir, "git", "tag", "-a", name, "-m", msg)
+}
+
+func (gitVCS) HasTag(dir, name string) (bool, error) {
+	tags, err := outputVCS(dir, "git", "tag", "--list", name)
+	return len(tags) != 0, err
+}
+
+func (gitVCS) Changes(dir string) ([]string, error) {
+	return outputVCS(dir, "git", "-c", "core.quotePath=false", "diff", "--name-only",
+		"--relative", "HEAD")
+}
+
+// hgVCS is Mercurial.
+type hgVCS struct{}
+
+func (hgVCS) Init(dir string) error {
+	out, err := exec.Command("hg", "init", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("hg init: %s", cmdError(out, err))
+	}
+	printInit(out)
+	return nil
+}
+
+func (hgVCS) IgnoreFileName() string { return ".hgignore" }
+func (hgVCS) IgnoreSyntax() string   { return IgnoreHgSyntax }
+
+func (hgVCS) Commit(dir string, files []string, msg, author string) error {
+	if err := runVCS(dir, "hg", append([]string{"add", "-q", "--"}, files...)...); err != nil {
+		return err
+	}
+	args := []string{"commit", "-m", msg}
+	if author != "" {
+		args = append(args, "-u", author)
+	}
+	return runVCS(dir, "hg", args...)
+}
+
+func (hgVCS) Detect(dir string) bool { return findUp(dir, ".hg") != "" }
+
+func (hgVCS) SetBranch(dir, name string) error {
+	return runVCS(dir, "hg", "branch", "-q", name)
+}
+
+// Tag adds the tag in a new commit, as Mercurial does.
+func (hgVCS) Tag(dir, name, msg string) error {
+	return runVCS(dir, "hg", "tag", "-m", msg, name)
+}
+
+func (hgVCS) HasTag(dir, name string) (bool, error) {
+	tags, err := outputVCS(dir, "hg", "tags", "-q")
+	return containsString(tags, name), err
+}
+
+// Changes lists the files relative to the directory, since a pattern is given.
+func (hgVCS) Changes(dir string) ([]string, error) {
+	return outputVCS(dir, "hg", "status", "-mard", "-n", ".")
+}
+
+func (hgVCS) SetRemote(dir, url string) error {
+	return appendFile(filepath.Join(dir, ".hg", "hgrc"),
+		fmt.Sprintf("[paths]\ndefault = %s\n", url))
+}
+
+// fossilVCS is Fossil. The repository is a file stored beside the project
+// directory, which is opened in it.
+type fossilVCS struct{}
+
+func (fossilVCS) Init(dir string) error {
+	repo, err := siblingRepo(dir, ".fossil")
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("fossil", "init", repo).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fossil init: %s", cmdError(out, err))
+	}
+	fmt.Printf("Initialized empty Fossil repository in %s\n", repo)
+
+	// The directory is not empty.
+	return runVCS(dir, "fossil", "open", "--force", repo)
+}
+
+func (fossilVCS) IgnoreFileName() string { return ".fossil-settings/ignore-glob" }
+func (fossilVCS) IgnoreSyntax() string   { return IgnoreGlobSyntax }
+
+func (fossilVCS) Commit(dir string, files []string, msg, author string) error {
+	if err := runVCS(dir, "fossil", append([]string{"add", "--force", "--"}, files...)...); err != nil {
+		return err
+	}
+	args := []string{"commit", "-m", msg}
+	if author != "" {
+		args = append(args, "--user-override", author)
+	}
+	return runVCS(dir, "fossil", args...)
+}
+
+func (fossilVCS) Detect(dir string) bool {
+	return findUp(dir, ".fslckout") != "" || findUp(dir, "_FOSSIL_") != ""
+}
+
+func (fossilVCS) SetRemote(dir, url string) error {
+	return runVCS(dir, "fossil", "remote", "add", _REMOTE, url)
+}
+
+func (fossilVCS) Tag(dir, name, msg string) error {
+	return runVCS(dir, "fossil", "tag", "add", name, "current")
+}
+
+func (fossilVCS) HasTag(dir, name string) (bool, error) {
+	tags, err := outputVCS(dir, "fossil", "tag", "list")
+	return containsString(tags, name), err
+}
+
+// svnVCS is Subversion. The repository is created beside the project
+// directory, which is checked out from it. The patterns to ignore are stored
+// in the property "svn:ignore" of the root directory.
+type svnVCS struct{}
+
+func (svnVCS) Init(dir string) error {
+	repo, err := siblingRepo(dir, ".svnrepo")
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("svnadmin", "create", repo).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("svnadmin create: %s", cmdError(out, err))
+	}
+	fmt.Printf("Initialized empty Subversion repository in %s\n", repo)
+
+	// The directory is not empty.
+	return runVCS(dir, "svn", "checkout", "-q", "--force",
+		"file://"+filepath.ToSlash(repo), ".")
+}
+
+func (svnVCS) IgnoreFileName() string { return "" }
+func (svnVCS) IgnoreSyntax() string   { return IgnoreSvnSyntax }
+
+func (svnVCS) Commit(dir string, files []string, msg, author string) error {
+	args := append([]string{"add", "-q", "--parents", "--no-ignore", "--"}, files...)
+	if err := runVCS(dir, "svn", args...); err != nil {
+		return err
+	}
+
+	args = []string{"commit", "-q", "-m", msg}
+	if author != "" {
+		// The author is the user name.
+		name, _ := splitAddress(author)
+		if name == "" {
+			name = author
+		}
+		args = append(args, "--username", name)
+	}
+	return runVCS(dir, "svn", args...)
+}
+
+func (svnVCS) Detect(dir string) bool { return findUp(dir, ".svn") != "" }
+
+func (svnVCS) SetIgnore(dir string, patterns []byte) error {
+	return svnPropset(dir, "svn:ignore", patterns)
+}
+
+// svnPropset sets the property of the root directory.
+func svnPropset(dir, name string, value []byte) error {
+	f, err := os.CreateTemp("", "svnprop")
+	if err != nil {
+		return fmt.Errorf("file error: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(value); err != nil {
+		f.Close()
+		return fmt.Errorf("file error: %s", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("file error: %s", err)
+	}
+	return runVCS(dir, "svn", "propset", "-q", name, "-F", f.Name(), ".")
+}
+
+// jjVCS is Jujutsu, with a Git repository as storage. The default branch is
+// a bookmark.
+type jjVCS struct{}
+
+func (jjVCS) Init(dir string) error {
+	out, err := exec.Command("jj", "git", "init", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("jj git init: %s", cmdError(out, err))
+	}
+	printInit(out)
+	return nil
+}
+
+func (jjVCS) IgnoreFileName() string { return ".gitignore" }
+func (jjVCS) IgnoreSyntax() string   { return IgnoreGitSyntax }
+
+// Commit describes the working-copy change, which has every file, and starts
+// a new one on top of it.
+func (jjVCS) Commit(dir string, files []string, msg, author string) error {
+	if err := runVCS(dir, "jj", append([]string{"file", "track", "--"}, files...)...); err != nil {
+		return err
+	}
+	args := []string{"describe", "-m", msg}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	if err := runVCS(dir, "jj", args...); err != nil {
+		return err
+	}
+	return runVCS(dir, "jj", "new")
+}
+
+func (jjVCS) Detect(dir string) bool { return findUp(dir, ".jj") != "" }
+
+func (jjVCS) SetBranch(dir, name string) error {
+	return runVCS(dir, "jj", "bookmark", "create", name, "-r", "@")
+}
+
+func (jjVCS) SetRemote(dir, url string) error {
+	return runVCS(dir, "jj", "git", "remote", "add", _REMOTE, url)
+}
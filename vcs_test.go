@@ -0,0 +1,400 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInitGit(t *testing.T) {
+	setDataDir(t)
+	setGitIdentity(t)
+
+	remote, err := filepath.Abs("remote.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, ".", "init", "-q", "--bare", remote)
+
+	// Without email, the identity of Git is used.
+	p := newTestProject(t, &Conf{
+		VCS:       "git",
+		Branch:    "trunk",
+		Remote:    remote,
+		Commit:    true,
+		CommitMsg: "First commit",
+	})
+	if err = p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if got := git(t, dir, "symbolic-ref", "--short", "HEAD"); got != "trunk" {
+		t.Errorf("branch: got %q, want %q", got, "trunk")
+	}
+	if got := git(t, dir, "remote", "get-url", _REMOTE); got != remote {
+		t.Errorf("remote: got %q, want %q", got, remote)
+	}
+	if got := git(t, dir, "log", "--format=%s|%an <%ae>"); got != "First commit|Git User <git@example.com>" {
+		t.Errorf("commit: got %q", got)
+	}
+	files := git(t, dir, "ls-files")
+	for _, v := range []string{"README.md", "go.mod", ".gitignore", _MANIFEST} {
+		if !strings.Contains("\n"+files+"\n", "\n"+v+"\n") {
+			t.Errorf("file %q not committed; got:\n%s", v, files)
+		}
+	}
+	if got := git(t, dir, "status", "--porcelain"); got != "" {
+		t.Errorf("files not committed:\n%s", got)
+	}
+
+	git(t, dir, "push", "-q", _REMOTE, "trunk")
+	if got := git(t, remote, "log", "--format=%s", "trunk"); got != "First commit" {
+		t.Errorf("remote log: got %q", got)
+	}
+
+	// With email, the author is the one of the project.
+	p = newTestProject(t, &Conf{Project: "Other", VCS: "git", Commit: true, Email: "jane@example.com"})
+	if err = p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, p.cfg.Program, "log", "--format=%an <%ae>|%s"); got != "Jane Doe <jane@example.com>|"+_COMMIT_MSG {
+		t.Errorf("commit: got %q", got)
+	}
+}
+
+// fakeVCS puts in the PATH scripts with the names of the commands, which log
+// their arguments, and returns the directory of the scripts.
+// The command "hg init" creates the directory ".hg", and "svn propset" copies
+// the file of the property to the directory of the scripts.
+func fakeVCS(t *testing.T, names ...string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake commands are shell scripts")
+	}
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo "${0##*/} $*" >>'` + filepath.Join(bin, "log") + `'
+case "${0##*/} $1" in
+"hg init") mkdir -p "$2/.hg" ;;
+"svn propset") cp "$5" '` + bin + `'/"$3" ;;
+esac
+`
+	for _, v := range names {
+		if err := os.WriteFile(filepath.Join(bin, v), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return bin
+}
+
+// fakeLog returns the commands logged by the scripts of fakeVCS, and removes
+// the log.
+func fakeLog(t *testing.T, bin string) []string {
+	t.Helper()
+	name := filepath.Join(bin, "log")
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(name)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestInitFakeVCS(t *testing.T) {
+	const author = "Jane Doe <jane@example.com>"
+
+	setDataDir(t)
+	bin := fakeVCS(t, "hg", "fossil", "svnadmin", "svn", "jj")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(wd, "test")
+
+	tests := []struct {
+		vcs    string
+		branch string
+		remote string
+		want   []string // prefixes of the commands
+	}{
+		{"hg", "trunk", "https", []string{
+			"hg init test",
+			"hg branch -q trunk",
+			"hg add -q -- ",
+			"hg commit -m " + _COMMIT_MSG + " -u " + author,
+		}},
+		{"fossil", "", "ssh", []string{
+			"fossil init " + repo + ".fossil",
+			"fossil open --force " + repo + ".fossil",
+			"fossil remote add " + _REMOTE + " ssh://example.com/jane/test",
+			"fossil add --force -- ",
+			"fossil commit -m " + _COMMIT_MSG + " --user-override " + author,
+		}},
+		{"svn", "", "", []string{
+			"svnadmin create " + repo + ".svnrepo",
+			"svn checkout -q --force file://" + filepath.ToSlash(repo) + ".svnrepo .",
+			"svn propset -q svn:auto-props -F ",
+			"svn propset -q svn:ignore -F ",
+			"svn add -q --parents --no-ignore -- ",
+			"svn commit -q -m " + _COMMIT_MSG + " --username Jane Doe",
+		}},
+		{"jj", "trunk", "ssh", []string{
+			"jj git init test",
+			"jj bookmark create trunk -r @",
+			"jj git remote add " + _REMOTE + " git@example.com:jane/test.git",
+			"jj file track -- ",
+			"jj describe -m " + _COMMIT_MSG + " --author " + author,
+			"jj new",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vcs, func(t *testing.T) {
+			p := newTestProject(t, &Conf{
+				VCS:         tt.vcs,
+				Branch:      tt.branch,
+				Remote:      tt.remote,
+				ImportPaths: []string{"example.com/jane"},
+				Commit:      true,
+				Email:       "jane@example.com",
+			})
+			if err := p.Create(); err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(p.cfg.Program)
+
+			got := fakeLog(t, bin)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got commands:\n%s", strings.Join(got, "\n"))
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("command #%d: got %q, want prefix %q", i, got[i], want)
+				}
+				// The files to add are given, with the manifest.
+				if strings.HasSuffix(want, " -- ") && (!strings.Contains(got[i], " go.mod") ||
+					!strings.Contains(got[i], " "+_MANIFEST)) {
+					t.Errorf("files not added: %q", got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteURL(t *testing.T) {
+	tests := []struct {
+		vcs, remote string
+		imports     []string
+		want        string
+		err         string
+	}{
+		{"git", "", nil, "", ""},
+		{"git", "git@github.com:jane/$.git", nil, "git@github.com:jane/app.git", ""},
+		{"hg", "ssh://hg@example.com/$/$", nil, "ssh://hg@example.com/app/app", ""},
+		{"git", "ssh", []string{"github.com/jane"}, "git@github.com:jane/app.git", ""},
+		{"git", "https", []string{"github.com/jane"}, "https://github.com/jane/app.git", ""},
+		{"jj", "ssh", []string{"github.com/jane"}, "git@github.com:jane/app.git", ""},
+		{"jj", "https", []string{"github.com/jane"}, "https://github.com/jane/app.git", ""},
+		{"hg", "ssh", []string{"example.com/jane"}, "ssh://example.com/jane/app", ""},
+		{"hg", "https", []string{"example.com/jane"}, "https://example.com/jane/app", ""},
+		{"fossil", "ssh", []string{"example.com/jane"}, "ssh://example.com/jane/app", ""},
+		{"fossil", "https", []string{"example.com/jane"}, "https://example.com/jane/app", ""},
+		{"git", "ssh", nil, "", `remote "ssh" requires the import path`},
+		{"git", "https", []string{"jane"}, "", `remote "https": no host in import path: "jane/app"`},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{VCS: tt.vcs, Remote: tt.remote, Program: "app", ImportPaths: tt.imports}
+
+		got, err := c.RemoteURL()
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s %q: got error %v, want %q", tt.vcs, tt.remote, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %s", tt.vcs, tt.remote, err)
+		} else if got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.vcs, tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRepository(t *testing.T) {
+	tests := []struct {
+		vcs, branch, remote string
+		err                 string
+	}{
+		{"hg", "trunk", "https://example.com/app", ""},
+		{"jj", "trunk", "https://example.com/app.git", ""},
+		{"fossil", "", "https://example.com/app", ""},
+		{"fossil", "trunk", "", "the branch is not supported for Fossil"},
+		{"svn", "trunk", "", "the branch is not supported for Subversion"},
+		{"svn", "", "https://example.com/app", "the remote is not supported for Subversion"},
+	}
+
+	for _, tt := range tests {
+		c := &Conf{VCS: tt.vcs, Branch: tt.branch, Remote: tt.remote, Program: "app"}
+
+		err := c.checkRepository()
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %s", tt.vcs, err)
+		} else if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.vcs, err, tt.err)
+		}
+	}
+}
+
+// runCmd runs the command in the directory "dir", returning its output.
+func runCmd(t *testing.T, dir, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %s", name, strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// lookVCS skips the test when some command is not found.
+func lookVCS(t *testing.T, names ...string) {
+	t.Helper()
+	for _, v := range names {
+		if _, err := exec.LookPath(v); err != nil {
+			t.Skip(v + " not found")
+		}
+	}
+}
+
+func TestInitHg(t *testing.T) {
+	lookVCS(t, "hg")
+	setDataDir(t)
+	t.Setenv("HGRCPATH", "")
+	t.Setenv("HGPLAIN", "1")
+
+	p := newTestProject(t, &Conf{
+		VCS: "hg", Branch: "trunk", Remote: "https://example.com/test",
+		Commit: true, Email: "jane@example.com",
+	})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if got := DetectVCS(dir); got != "hg" {
+		t.Errorf("detect: got %q", got)
+	}
+	if got := runCmd(t, dir, "hg", "branch"); got != "trunk" {
+		t.Errorf("branch: got %q", got)
+	}
+	if got := runCmd(t, dir, "hg", "paths", "default"); got != "https://example.com/test" {
+		t.Errorf("remote: got %q", got)
+	}
+	if got := runCmd(t, dir, "hg", "log", "--template", "{desc}|{author}"); got != _COMMIT_MSG+"|Jane Doe <jane@example.com>" {
+		t.Errorf("commit: got %q", got)
+	}
+	if got := runCmd(t, dir, "hg", "status"); got != "" {
+		t.Errorf("files not committed:\n%s", got)
+	}
+}
+
+func TestInitFossil(t *testing.T) {
+	lookVCS(t, "fossil")
+	setDataDir(t)
+	t.Setenv("USER", "jane")
+
+	p := newTestProject(t, &Conf{VCS: "fossil", Commit: true, Email: "jane@example.com"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if got := DetectVCS(dir); got != "fossil" {
+		t.Errorf("detect: got %q", got)
+	}
+	files := runCmd(t, dir, "fossil", "ls")
+	for _, v := range []string{"README.md", "go.mod", _MANIFEST} {
+		if !strings.Contains("\n"+files+"\n", "\n"+v+"\n") {
+			t.Errorf("file %q not committed; got:\n%s", v, files)
+		}
+	}
+	if got := runCmd(t, dir, "fossil", "changes"); got != "" {
+		t.Errorf("files not committed:\n%s", got)
+	}
+}
+
+func TestInitSvn(t *testing.T) {
+	lookVCS(t, "svn", "svnadmin")
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{VCS: "svn", Commit: true, Email: "jane@example.com"})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if got := DetectVCS(dir); got != "svn" {
+		t.Errorf("detect: got %q", got)
+	}
+	if got := runCmd(t, dir, "svn", "propget", "svn:ignore", "."); got == "" {
+		t.Error("svn:ignore not set")
+	}
+	if got := runCmd(t, dir, "svn", "status"); got != "" {
+		t.Errorf("files not committed:\n%s", got)
+	}
+	if got := runCmd(t, dir, "svn", "log", "-q", "file://"+filepath.ToSlash(mustAbs(t, dir))+".svnrepo"); !strings.Contains(got, "| Jane Doe |") {
+		t.Errorf("log: got %q", got)
+	}
+}
+
+func TestInitJj(t *testing.T) {
+	lookVCS(t, "jj")
+	setDataDir(t)
+	t.Setenv("JJ_CONFIG", os.DevNull)
+	t.Setenv("JJ_USER", "Jj User")
+	t.Setenv("JJ_EMAIL", "jj@example.com")
+
+	p := newTestProject(t, &Conf{
+		VCS: "jj", Branch: "trunk", Remote: "https://example.com/test.git",
+		Commit: true, Email: "jane@example.com",
+	})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	if got := DetectVCS(dir); got != "jj" {
+		t.Errorf("detect: got %q", got)
+	}
+	if got := runCmd(t, dir, "jj", "log", "--no-graph", "-r", "trunk",
+		"-T", `description.first_line() ++ "|" ++ author.name()`); got != _COMMIT_MSG+"|Jane Doe" {
+		t.Errorf("commit: got %q", got)
+	}
+	if got := runCmd(t, dir, "jj", "git", "remote", "list"); !strings.HasPrefix(got, _REMOTE+" https://example.com/test.git") {
+		t.Errorf("remote: got %q", got)
+	}
+}
+
+// mustAbs returns the absolute path of the file.
+func mustAbs(t *testing.T, name string) string {
+	t.Helper()
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
@@ -0,0 +1,300 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"bufio"
+	"bytes"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// VCS configuration files, from the most specific to the most general.
+// The local ones are relative to the root of the repository, and the global
+// ones to the home directory; "$XDG" is the XDG configuration directory.
+var (
+	listConfigVCSLocal = map[string][]string{
+		"bzr": {".bzr/branch/branch.conf"},
+		"git": {".git/config"},
+		"hg":  {".hg/hgrc"},
+		"jj":  {".jj/repo/config.toml"},
+	}
+	listConfigVCSGlobal = map[string][]string{
+		"bzr": {"$XDG/breezy/breezy.conf", ".bazaar/bazaar.conf"},
+		"git": {".gitconfig", "$XDG/git/config"},
+		"hg":  {".hgrc", "$XDG/hg/hgrc"},
+		"jj":  {".jjconfig.toml", "$XDG/jj/config.toml"},
+	}
+)
+
+// vcsUser is the user identity set in the configuration of a VCS.
+type vcsUser struct {
+	name, nameSource   string // the source is the file where it was found
+	email, emailSource string
+	org, orgSource     string
+}
+
+// readVCSUser returns the user identity configured for the VCS, looking for
+// it from the repository which contains the directory "dir", if any, to the
+// global configuration. Returns false if it is not found.
+//
+// The organization is not a setting of the VCSs, so it is got from the keys
+// "user.organization" of Git and Jujutsu, and "ui.organization" of Mercurial.
+// The files are parsed directly, without running the VCS tools.
+func readVCSUser(vcs, dir string) (vcsUser, bool) {
+	var files []string
+
+	if root := findUp(dir, "."+vcs); root != "" {
+		for _, v := range listConfigVCSLocal[vcs] {
+			files = append(files, filepath.Join(root, filepath.FromSlash(v)))
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	for _, v := range listConfigVCSGlobal[vcs] {
+		switch {
+		case strings.HasPrefix(v, "$XDG/"):
+			if xdg != "" {
+				files = append(files, filepath.Join(xdg, filepath.FromSlash(v[5:])))
+			}
+		case home != "":
+			files = append(files, filepath.Join(home, filepath.FromSlash(v)))
+		}
+	}
+
+	user := vcsUser{}
+	for _, file := range files {
+		entries, err := readConfigINI(file, 0)
+		if err != nil {
+			continue
+		}
+
+		var name, email, org string
+		for _, e := range entries {
+			switch vcs {
+			case "git", "jj":
+				switch e.key {
+				case "user.name":
+					name = e.value
+				case "user.email":
+					email = e.value
+				case "user.organization":
+					org = e.value
+				}
+			case "hg":
+				switch e.key {
+				case "ui.username":
+					name, email = splitAddress(e.value)
+				case "ui.organization":
+					org = e.value
+				}
+			case "bzr":
+				// The identity set by "bzr whoami" is stored like an email.
+				if e.key == "default.email" || e.key == ".email" {
+					name, email = splitAddress(e.value)
+				}
+			}
+		}
+
+		// Values not found in a file are got from the next one.
+		if user.name == "" && name != "" {
+			user.name, user.nameSource = name, file
+		}
+		if user.email == "" && email != "" {
+			user.email, user.emailSource = email, file
+		}
+		if user.org == "" && org != "" {
+			user.org, user.orgSource = org, file
+		}
+		if user.name != "" && user.email != "" && user.org != "" {
+			break
+		}
+	}
+
+	return user, user.name != "" || user.email != "" || user.org != ""
+}
+
+// splitAddress splits an address like "Name <email>" in its parts.
+func splitAddress(s string) (name, email string) {
+	s = strings.TrimSpace(s)
+
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Name, addr.Address
+	}
+	if strings.Contains(s, "@") && !strings.Contains(s, " ") {
+		return "", s
+	}
+	return s, ""
+}
+
+// findUp returns the first directory from "dir" up to the root which has the
+// entry "name". Returns an empty string if it is not found.
+func findUp(dir, name string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err = os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// iniEntry is a value of an INI-like configuration file.
+type iniEntry struct {
+	key   string // "section.key", in lower case
+	value string
+}
+
+// _MAX_INCLUDE is the maximum depth of included configuration files.
+const _MAX_INCLUDE = 10
+
+// reINIKey matches a key without value, like the boolean ones of Git.
+var reINIKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// readConfigINI reads an INI-like configuration file, as used by Git,
+// Mercurial and Bazaar, and the simple TOML files of Jujutsu. The entries are
+// returned in order, so the last value of a key is the one to use. The files
+// set by Git "include.path" and Mercurial "%include" are read in place, up to
+// the depth _MAX_INCLUDE.
+func readConfigINI(file string, depth int) ([]iniEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]iniEntry, 0)
+	section := ""
+	continued := false // the previous line is an entry, which could be continued
+
+	include := func(path string) {
+		if depth >= _MAX_INCLUDE {
+			return
+		}
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		if inc, err := readConfigINI(path, depth+1); err == nil {
+			entries = append(entries, inc...)
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "", trimmed[0] == '#', trimmed[0] == ';':
+			continued = false
+			continue
+
+		case strings.HasPrefix(trimmed, "%include "):
+			continued = false
+			include(strings.TrimSpace(trimmed[len("%include "):]))
+			continue
+
+		case trimmed[0] == '[':
+			continued = false
+			end := strings.IndexByte(trimmed, ']')
+			if end == -1 {
+				continue
+			}
+			// Git subsections, as in `[remote "origin"]`.
+			header := strings.Fields(trimmed[1:end])
+			if len(header) == 0 {
+				section = ""
+				continue
+			}
+			section = strings.ToLower(header[0])
+			if len(header) > 1 {
+				section += "." + strings.Trim(strings.Join(header[1:], " "), `"`)
+			}
+			continue
+
+		case line[0] == ' ' || line[0] == '\t':
+			// Mercurial continuation lines; not the boolean keys of Git, which
+			// are indented too.
+			if n := len(entries); continued && n != 0 && !strings.Contains(trimmed, "=") &&
+				!reINIKey.MatchString(trimmed) {
+				entries[n-1].value += "\n" + trimmed
+				continue
+			}
+		}
+		continued = true
+
+		key, value := trimmed, "true"
+		if i := strings.IndexAny(trimmed, "=:"); i != -1 {
+			key, value = trimmed[:i], trimmed[i+1:]
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = unquoteINI(value)
+
+		if section != "" {
+			key = section + "." + key
+		} else {
+			key = "." + key
+		}
+
+		if key == "include.path" {
+			include(value)
+			continue
+		}
+		entries = append(entries, iniEntry{key, value})
+	}
+
+	return entries, scanner.Err()
+}
+
+// unquoteINI removes the comments at the end of a value, and the quotes.
+func unquoteINI(s string) string {
+	var buf strings.Builder
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			default:
+				buf.WriteByte(s[i])
+			}
+		case c == '"':
+			inQuote = !inQuote
+		case (c == '#' || c == ';') && !inQuote:
+			return strings.TrimSpace(buf.String())
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
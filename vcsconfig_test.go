@@ -0,0 +1,212 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadConfigINI(t *testing.T) {
+	home := setConfigHome(t)
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "inc", "user.inc"), "[user]\n\temail = jane@example.com\n")
+	writeTestFile(t, filepath.Join(home, "home.inc"), "[user]\n\tname = Home Name\n")
+
+	tests := []struct {
+		name    string
+		data    string
+		entries []iniEntry
+	}{
+		{"git", `# comment
+[user]
+	name = "Jane Doe" ; comment
+	email = jane@example.com # comment
+[remote "origin"]
+	url = git@example.com:jane/foo.git
+[core]
+	bare
+`, []iniEntry{
+			{"user.name", "Jane Doe"},
+			{"user.email", "jane@example.com"},
+			{"remote.origin.url", "git@example.com:jane/foo.git"},
+			{"core.bare", "true"},
+		}},
+		{"quotes and escapes", `[alias]
+	hi = "echo \"hi\" # not a comment"
+	tab = a\tb
+`, []iniEntry{
+			{"alias.hi", `echo "hi" # not a comment`},
+			{"alias.tab", "a\tb"},
+		}},
+		{"git booleans", "[core]\n\tbare = false\n\tfilemode\n", []iniEntry{
+			{"core.bare", "false"},
+			{"core.filemode", "true"},
+		}},
+		{"hg continuation", `[ui]
+username = Jane Doe
+  <jane@example.com>
+`, []iniEntry{
+			{"ui.username", "Jane Doe\n<jane@example.com>"},
+		}},
+		{"toml of jj", `[user]
+name = "Jane Doe"
+email = "jane@example.com"
+`, []iniEntry{
+			{"user.name", "Jane Doe"},
+			{"user.email", "jane@example.com"},
+		}},
+		{"without section", "email: jane@example.com\n", []iniEntry{
+			{".email", "jane@example.com"},
+		}},
+		{"git include", `[user]
+	name = Jane Doe
+[include]
+	path = inc/user.inc
+	path = ~/home.inc
+[core]
+	editor = vi
+`, []iniEntry{
+			{"user.name", "Jane Doe"},
+			{"user.email", "jane@example.com"},
+			{"user.name", "Home Name"},
+			{"core.editor", "vi"},
+		}},
+		{"hg include", "%include inc/user.inc\n[ui]\nusername = Jane\n", []iniEntry{
+			{"user.email", "jane@example.com"},
+			{"ui.username", "Jane"},
+		}},
+		{"include not found", "%include missing.inc\n[ui]\nusername = Jane\n", []iniEntry{
+			{"ui.username", "Jane"},
+		}},
+	}
+
+	for i, tt := range tests {
+		file := filepath.Join(dir, fmt.Sprintf("config%d", i))
+		writeTestFile(t, file, tt.data)
+
+		entries, err := readConfigINI(file, 0)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(entries, tt.entries) {
+			t.Errorf("%s: got %q, want %q", tt.name, entries, tt.entries)
+		}
+	}
+
+	// The recursion of includes stops at the maximum depth.
+	loop := filepath.Join(dir, "loop")
+	writeTestFile(t, loop, "[include]\n\tpath = loop\n[user]\n\tname = Jane\n")
+
+	entries, err := readConfigINI(loop, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != _MAX_INCLUDE+1 {
+		t.Errorf("include loop: got %d entries, want %d", len(entries), _MAX_INCLUDE+1)
+	}
+}
+
+func TestReadVCSUser(t *testing.T) {
+	home := setConfigHome(t)
+	xdg := filepath.Join(home, ".config")
+	repo := t.TempDir()
+
+	// Global files
+	writeTestFile(t, filepath.Join(home, ".gitconfig"),
+		"[user]\n\tname = Jane Doe\n\temail = jane@example.com\n\torganization = Acme\n")
+	writeTestFile(t, filepath.Join(xdg, "hg", "hgrc"),
+		"[ui]\nusername = Jane Doe <jane@example.com>\norganization = Acme\n")
+	writeTestFile(t, filepath.Join(home, ".jjconfig.toml"),
+		"[user]\nname = \"Jane Doe\"\nemail = \"jane@example.com\"\n")
+	writeTestFile(t, filepath.Join(home, ".bazaar", "bazaar.conf"),
+		"[DEFAULT]\nemail = Jane Doe <jane@example.com>\n")
+
+	// The local files are read before of the global ones.
+	writeTestFile(t, filepath.Join(repo, ".git", "config"), "[user]\n\temail = jane@work.example.com\n")
+	writeTestFile(t, filepath.Join(repo, ".hg", "hgrc"), "[ui]\norganization = Work\n")
+
+	tests := []struct {
+		vcs, dir string
+		user     vcsUser
+	}{
+		{"git", home, vcsUser{
+			"Jane Doe", filepath.Join(home, ".gitconfig"),
+			"jane@example.com", filepath.Join(home, ".gitconfig"),
+			"Acme", filepath.Join(home, ".gitconfig"),
+		}},
+		{"git", filepath.Join(repo, "sub"), vcsUser{
+			"Jane Doe", filepath.Join(home, ".gitconfig"),
+			"jane@work.example.com", filepath.Join(repo, ".git", "config"),
+			"Acme", filepath.Join(home, ".gitconfig"),
+		}},
+		{"hg", repo, vcsUser{
+			"Jane Doe", filepath.Join(xdg, "hg", "hgrc"),
+			"jane@example.com", filepath.Join(xdg, "hg", "hgrc"),
+			"Work", filepath.Join(repo, ".hg", "hgrc"),
+		}},
+		{"jj", home, vcsUser{
+			"Jane Doe", filepath.Join(home, ".jjconfig.toml"),
+			"jane@example.com", filepath.Join(home, ".jjconfig.toml"),
+			"", "",
+		}},
+		{"bzr", home, vcsUser{
+			"Jane Doe", filepath.Join(home, ".bazaar", "bazaar.conf"),
+			"jane@example.com", filepath.Join(home, ".bazaar", "bazaar.conf"),
+			"", "",
+		}},
+	}
+
+	for _, tt := range tests {
+		user, found := readVCSUser(tt.vcs, tt.dir)
+		if !found || user != tt.user {
+			t.Errorf("%s: got %+v, %v; want %+v", tt.vcs, user, found, tt.user)
+		}
+	}
+
+	if _, found := readVCSUser("fossil", home); found {
+		t.Error("fossil: found")
+	}
+}
+
+func TestVCSConfig(t *testing.T) {
+	home := setConfigHome(t)
+	writeTestFile(t, filepath.Join(home, ".gitconfig"),
+		"[user]\n\tname = Jane Doe\n\temail = jane@example.com\n\torganization = Acme\n")
+
+	// The values set are kept.
+	c := &Conf{VCS: "git", Email: "doe@example.com"}
+	c.vcsConfig()
+	if c.Author != "Jane Doe" || c.Email != "doe@example.com" || c.Org != "Acme" {
+		t.Errorf("got author %q, email %q, org %q", c.Author, c.Email, c.Org)
+	}
+	source := filepath.Join(home, ".gitconfig")
+	if c.Origins["author"] != source || c.Origins["org"] != source || c.Origins["email"] != "" {
+		t.Errorf("got origins %q", c.Origins)
+	}
+}
+
+func TestSplitAddress(t *testing.T) {
+	tests := []struct {
+		in, name, email string
+	}{
+		{"Jane Doe <jane@example.com>", "Jane Doe", "jane@example.com"},
+		{"<jane@example.com>", "", "jane@example.com"},
+		{"jane@example.com", "", "jane@example.com"},
+		{"  Jane Doe  ", "Jane Doe", ""},
+	}
+
+	for _, tt := range tests {
+		if name, email := splitAddress(tt.in); name != tt.name || email != tt.email {
+			t.Errorf("%q: got (%q, %q), want (%q, %q)", tt.in, name, email, tt.name, tt.email)
+		}
+	}
+}
@@ -11,10 +11,8 @@ import (
 	"fmt"
 	"go/build"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
-	"strings"
 	"text/template"
 )
 
@@ -23,33 +21,34 @@ const (
 	_DIR_PERM  = 0755
 	_FILE_PERM = 0644
 
-	_COMMENT_CHAR = "//" // For comments in source code files
-	_HEADER_CHAR  = "="  // Header under the project name
+	_HEADER_CHAR = "=" // Header under the project name
 
 	// Subdirectory where is installed through "go get"
 	_DATA_PATH = "github.com/tredoe/wizard/data"
 
-	_README      = "README.md"
-	_USER_CONFIG = ".gowizard" // Configuration file per user
+	_README = "README.md"
+
+	// Configuration files
+	_USER_CONFIG    = ".gowizard"      // per user, in the home directory; old location
+	_CONFIG_DIR     = "gowizard"       // in the XDG configuration directories
+	_CONFIG_FILE    = "config.yaml"    // in _CONFIG_DIR
+	_PROJECT_CONFIG = ".gowizard.yaml" // per project, in the directory or a parent one
+
+	_ENV_PREFIX = "GOWIZARD_" // for environment variables like GOWIZARD_AUTHOR
 )
 
 // Version control systems (VCS)
 var (
-	ListVCSsorted = []string{"bzr", "git", "hg", "none"}
+	ListVCSsorted = []string{"fossil", "git", "hg", "jj", "none", "svn"}
 
 	ListVCS = map[string]string{
-		"bzr":  "Bazaar",
-		"git":  "Git",
-		"hg":   "Mercurial",
-		"none": "none",
-	}
-
-	/*// VCS configuration files
-	listConfigVCS = map[string]string{
-		"bzr": ".bzr/branch/branch.conf",
-		"git": ".git/config",
-		"hg":  ".hg/hgrc",
-	}*/
+		"fossil": "Fossil",
+		"git":    "Git",
+		"hg":     "Mercurial",
+		"jj":     "Jujutsu",
+		"none":   "none",
+		"svn":    "Subversion",
+	}
 )
 
 // Available licenses
@@ -74,6 +73,24 @@ var (
 	}
 )
 
+// Project kinds; each one has its own layout.
+var (
+	ListKindSorted = []string{"command", "library", "library-with-command", "service"}
+
+	ListKind = map[string]string{
+		"command":              "program in a main package",
+		"library":              "package to be imported",
+		"library-with-command": "package, and its program in \"cmd/\"",
+		"service":              "HTTP server with graceful shutdown",
+	}
+)
+
+// Policies of the years of the copyright
+var ListCopyrightYears = map[string]string{
+	"fixed": "the year of creation of every file",
+	"vcs":   "the range of years of the changes of every file, from the VCS history",
+}
+
 // project represents all information to create a project.
 type project struct {
 	dataDir string             // directory with templates
@@ -94,97 +111,265 @@ func NewProject(cfg *Conf) (*project, error) {
 
 // Create creates a new project.
 func (p *project) Create() (err error) {
-	dirs := []string{
-		p.cfg.Program,
-		filepath.Join(p.cfg.Program, "doc"),
-		filepath.Join(p.cfg.Program, "testdata"),
+	if err = os.Mkdir(p.cfg.Program, _DIR_PERM); err != nil {
+		return fmt.Errorf("directory error: %s", err)
+	}
+
+	dirs := make([]string, 0)
+	if len(p.cfg.Modules) == 0 {
+		dirs = append(dirs, filepath.Join(p.cfg.Program, "testdata"))
+	}
+	for _, v := range p.cfg.Modules {
+		dirs = append(dirs, filepath.Join(p.cfg.Program, v, "testdata"))
 	}
 	for _, v := range dirs {
-		if err = os.Mkdir(v, _DIR_PERM); err != nil {
+		if err = os.MkdirAll(v, _DIR_PERM); err != nil {
 			return fmt.Errorf("directory error: %s", err)
 		}
 	}
 
-	p.parseLicense(_COMMENT_CHAR)
+	p.parseLicense()
 	p.parseProject()
 
 	if len(p.cfg.ImportPaths) != 0 {
 		p.cfg.ImportPath = path.Join(p.cfg.ImportPaths[0], p.cfg.Program)
 	}
+	if p.cfg.GoVersion == "" {
+		p.cfg.GoVersion = goVersion()
+	}
 
-	// Render project files
-
-	err = p.parseFromVar(filepath.Join(p.cfg.Program, p.cfg.Program)+".go", "Go")
+	files, err := p.render()
 	if err != nil {
 		return err
 	}
-	err = p.parseFromVar(filepath.Join(p.cfg.Program, "_"+p.cfg.Program)+"_test.go", "Test")
-	if err != nil {
-		return err
+	for _, f := range files {
+		if err = writeFile(filepath.Join(p.cfg.Program, f.name), f.data); err != nil {
+			return err
+		}
 	}
-	err = p.parseFromVar(filepath.Join(p.cfg.Program, "_example_test.go"), "Example")
-	if err != nil {
+	if err = newManifest(p.cfg, files).write(p.cfg.Program); err != nil {
 		return err
 	}
 
-	// Add license file
-
-	if p.cfg.License != "none" {
-		license := ListLowerLicense[p.cfg.License]
-		licenseDst := filepath.Join(p.cfg.Program, "LICENSE-"+license+".txt")
+	// == VCS
 
-		err = copyFile(licenseDst, filepath.Join(p.dataDir, license+".txt"))
+	if p.cfg.VCS != "none" {
+		names := make([]string, 0, len(files)+1)
+		for _, f := range files {
+			names = append(names, filepath.ToSlash(f.name))
+		}
+		ignore, err := p.renderIgnore()
 		if err != nil {
 			return err
 		}
+		if err = p.initVCS(append(names, _MANIFEST), ignore); err != nil {
+			return err
+		}
 	}
 
-	// Render common files
+	return nil
+}
 
-	err = p.parseFromVar(filepath.Join(p.cfg.Program, _README), "Readme")
-	if err != nil {
-		return err
+// renderedFile is a project file rendered in memory.
+type renderedFile struct {
+	name string // path relative to the project directory
+	data []byte
+}
+
+// render renders the files of the project, without writing them.
+// The templates have to be parsed before.
+func (p *project) render() ([]renderedFile, error) {
+	files := make([]renderedFile, 0)
+
+	add := func(name, tmplName string) error {
+		data, err := p.renderSource(name, tmplName)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		files = append(files, renderedFile{name, data})
+		return nil
 	}
-	err = p.parseFromVar(filepath.Join(p.cfg.Program, "CONTRIBUTORS.txt.md"), "Contributors")
-	if err != nil {
-		return err
+
+	// Project files
+
+	if len(p.cfg.Modules) == 0 {
+		layout, err := p.layout()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range layout {
+			if err := add(v.name, v.tmplName); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if err := add("go.work", "GoWork"); err != nil {
+			return nil, err
+		}
+		for _, v := range p.cfg.Modules {
+			modFiles, err := p.renderModule(v)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, modFiles...)
+		}
 	}
-	err = p.parseFromVar(filepath.Join(dirs[1], "_changelog.txt.md"), "Changelog")
+
+	// License files
+
+	licenses, err := p.renderLicenses()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	files = append(files, licenses...)
+
+	// Common files
+
+	if err := add(p.cfg.readmeFile(), "Readme"); err != nil {
+		return nil, err
+	}
+	if err := add(_CONTRIBUTORS, "Contributors"); err != nil {
+		return nil, err
+	}
+	if err := add(_CHANGELOG, "Changelog"); err != nil {
+		return nil, err
+	}
+	if name, tmplName := p.cfg.ciFile(); name != "" {
+		if err := add(name, tmplName); err != nil {
+			return nil, err
+		}
 	}
 
 	// The file AUTHORS is for copyright holders.
 	if p.cfg.License != "cc0" {
-		err = p.parseFromVar(filepath.Join(p.cfg.Program, "AUTHORS.txt.md"), "Authors")
-		if err != nil {
-			return err
+		if err := add(_AUTHORS, "Authors"); err != nil {
+			return nil, err
 		}
 	}
 
-	// == VCS
+	if v, ok := vcsBackends[p.cfg.VCS]; ok && v.IgnoreFileName() != "" {
+		data, err := p.renderIgnore()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, renderedFile{v.IgnoreFileName(), data})
+	}
+	if name, data := p.renderAttributes(); name != "" {
+		files = append(files, renderedFile{name, data})
+	}
 
-	if p.cfg.VCS != "none" {
-		ignoreFile := "." + p.cfg.VCS + "ignore"
-		if err = p.parseFromVar(filepath.Join(p.cfg.Program, ignoreFile),
-			"Ignore"); err != nil {
-			return err
+	// The files which can not have a header are annotated.
+	if p.cfg.Reuse {
+		data, err := p.renderReuse(files)
+		if err != nil {
+			return nil, err
 		}
+		files = append(files, renderedFile{_REUSE_TOML, data})
+	}
+
+	return files, nil
+}
+
+// ModulePath returns the path of the module in "go.mod": the import path or,
+// when it is not set, the program name.
+func (c *Conf) ModulePath() string {
+	if c.ImportPath == "" {
+		return c.Program
+	}
+	return c.ImportPath
+}
+
+// renderLicenses renders the files with the text of the licenses of the
+// project, and the notice file when it is required.
+func (p *project) renderLicenses() ([]renderedFile, error) {
+	files := make([]renderedFile, 0)
 
-		// Initialize VCS
-		out, err := exec.Command(p.cfg.VCS, "init", p.cfg.Program).CombinedOutput()
+	add := func(name, tmplName string) error {
+		data, err := p.renderSource(name, tmplName)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		files = append(files, renderedFile{name, data})
+		return nil
+	}
+
+	for _, v := range p.cfg.Licenses() {
+		license := ListLowerLicense[v]
+		name := "LICENSE-" + license + ".txt"
+		if p.cfg.Reuse {
+			name = path.Join(_REUSE_LICENSES, licenseSPDX[v]+".txt")
 		}
-		if out != nil {
-			out_ := string(out)
-			if wd, err := os.Getwd(); err == nil {
-				out_ = strings.Replace(out_, wd+string(os.PathSeparator), "", 1)
+
+		if v == "none" {
+			// REUSE requires the text of every license used.
+			if p.cfg.Reuse {
+				if err := add(name, "Proprietary"); err != nil {
+					return nil, err
+				}
 			}
+			continue
+		}
 
-			fmt.Print(out_)
+		data, err := os.ReadFile(filepath.Join(p.dataDir, license+".txt"))
+		if err != nil {
+			return nil, fmt.Errorf("license error: %s", err)
+		}
+		files = append(files, renderedFile{name, data})
+	}
+	// Section 4(d) of the Apache License.
+	if containsString(p.cfg.Licenses(), "apache") {
+		if err := add(_NOTICE, "Notice"); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return files, nil
+}
+
+// layoutFile is a source file of the layout of a project kind.
+type layoutFile struct {
+	name     string // path relative to the project directory
+	tmplName string
+}
+
+// layout returns the source files to create for the kind of project.
+func (p *project) layout() ([]layoutFile, error) {
+	library := []layoutFile{
+		{p.cfg.Program + ".go", "Go"},
+		{p.cfg.Program + "_test.go", "Test"},
+		{"example_test.go", "Example"},
+	}
+	cmdDir := path.Join("cmd", p.cfg.Program)
+
+	var files []layoutFile
+
+	switch p.cfg.Kind {
+	case "", "library":
+		files = library
+	case "command":
+		files = []layoutFile{
+			{"main.go", "Command"},
+			{"main_test.go", "CommandTest"},
+		}
+	case "library-with-command":
+		files = append(library,
+			layoutFile{path.Join(cmdDir, "main.go"), "Command"},
+			layoutFile{path.Join(cmdDir, "main_test.go"), "CommandTest"},
+		)
+	case "service":
+		if p.cfg.ImportPath == "" {
+			return nil, fmt.Errorf("kind %q requires an import path", p.cfg.Kind)
+		}
+		files = []layoutFile{
+			{path.Join(cmdDir, "main.go"), "Service"},
+			{path.Join(cmdDir, "main_test.go"), "ServiceTest"},
+			{"internal/server/server.go", "Server"},
+			{"internal/server/server_test.go", "ServerTest"},
+		}
+	default:
+		return nil, fmt.Errorf("unavailable kind: %q", p.cfg.Kind)
+	}
+
+	// The module, to build it with the go tool.
+	return append([]layoutFile{{"go.mod", "GoMod"}}, files...), nil
 }
@@ -0,0 +1,195 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"go/build"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setDataDir makes the data directory of this package to be found through a
+// temporary GOPATH, and changes to a temporary directory where to create the
+// projects, without the configuration of the user.
+func setDataDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gopath := t.TempDir()
+	dir := filepath.Join(gopath, "src", filepath.FromSlash(path.Dir(_DATA_PATH)))
+	if err = os.MkdirAll(filepath.Dir(dir), _DIR_PERM); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink(wd, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GO111MODULE", "off")
+	oldGopath := build.Default.GOPATH
+	build.Default.GOPATH = gopath
+	t.Cleanup(func() { build.Default.GOPATH = oldGopath })
+
+	setConfigHome(t)
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// newTestProject returns a project named "Test", created with the values of
+// cfg; the files are not written.
+func newTestProject(t *testing.T, cfg *Conf) *project {
+	t.Helper()
+	if cfg.Project == "" {
+		cfg.Project = "Test"
+	}
+	if cfg.License == "" {
+		cfg.License = "mpl"
+	}
+	if cfg.VCS == "" {
+		cfg.VCS = "none"
+	}
+	if cfg.Author == "" {
+		cfg.Author = "Jane Doe"
+	}
+
+	if err := cfg.SetNames(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.PostCheck(true, false); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProject(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// setGitIdentity sets the identity used by Git, without the configuration of
+// the user.
+func setGitIdentity(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+v+"_NAME", "Git User")
+		t.Setenv("GIT_"+v+"_EMAIL", "git@example.com")
+	}
+}
+
+// git runs the Git command in the directory "dir", returning its output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestLayout(t *testing.T) {
+	// The cache of the go tool, before of changing the home directory.
+	goEnv := []string{"GO111MODULE=on", "GOFLAGS=", "GOWORK=off"}
+	if out, err := exec.Command("go", "env", "GOCACHE").Output(); err == nil {
+		goEnv = append(goEnv, "GOCACHE="+strings.TrimSpace(string(out)))
+	}
+	setDataDir(t)
+
+	tests := []struct {
+		kind  string
+		files []string
+	}{
+		{"library", []string{"example_test.go", "go.mod", "hello.go", "hello_test.go"}},
+		{"command", []string{"go.mod", "main.go", "main_test.go"}},
+		{"library-with-command", []string{
+			"cmd/hello/main.go", "cmd/hello/main_test.go",
+			"example_test.go", "go.mod", "hello.go", "hello_test.go",
+		}},
+		{"service", []string{
+			"cmd/hello/main.go", "cmd/hello/main_test.go", "go.mod",
+			"internal/server/server.go", "internal/server/server_test.go",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := newTestProject(t, &Conf{Project: "Hello", Kind: tt.kind, ImportPaths: []string{"example.com/" + tt.kind}})
+		if err := os.Mkdir(tt.kind, _DIR_PERM); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(tt.kind); err != nil {
+			t.Fatal(err)
+		}
+		err := p.Create()
+		os.Chdir("..")
+		if err != nil {
+			t.Fatalf("%s: %s", tt.kind, err)
+		}
+		dir := filepath.Join(tt.kind, "hello")
+
+		files := make([]string, 0)
+		err = filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if rel, _ := filepath.Rel(dir, name); strings.HasSuffix(rel, ".go") || rel == "go.mod" {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(files, tt.files) {
+			t.Errorf("%s: got files %q, want %q", tt.kind, files, tt.files)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "module " + p.cfg.ImportPath + "\n"; !strings.HasPrefix(string(data), want) {
+			t.Errorf("%s: go.mod: got\n%s\nwant the start %q", tt.kind, data, want)
+		}
+
+		// The project builds and its tests pass.
+		if _, err = exec.LookPath("go"); err != nil || testing.Short() {
+			continue
+		}
+		for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+			cmd := exec.Command("go", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), goEnv...)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("%s: go %s: %s\n%s", tt.kind, args[0], err, out)
+			}
+		}
+	}
+}
+
+func TestLayoutService(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{Project: "Hello", Kind: "service"})
+	if _, err := p.layout(); err == nil || !strings.Contains(err.Error(), "import path") {
+		t.Errorf("service without import path: got error %v", err)
+	}
+}
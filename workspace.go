@@ -0,0 +1,145 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// _GO_VERSION is the version of Go used in "go.mod" and "go.work" files when
+// it cannot be got from the toolchain.
+const _GO_VERSION = "1.21"
+
+// goVersion returns the language version, "major.minor", of the Go toolchain.
+func goVersion() string {
+	v := strings.TrimPrefix(runtime.Version(), "go")
+
+	fields := strings.SplitN(v, ".", 3)
+	if len(fields) < 2 || fields[0] == "" || strings.ContainsAny(v, " +") {
+		return _GO_VERSION
+	}
+	return fields[0] + "." + strings.TrimRightFunc(fields[1], func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+}
+
+// checkModule checks the name of a module directory in the workspace. For the
+// kinds with a library, its base is the name of the package, so it has to be
+// an identifier of Go.
+func checkModule(name, kind string) error {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name ||
+		name == "." || strings.HasPrefix(name, "../") || name == ".." {
+		return fmt.Errorf("invalid module directory: %q", name)
+	}
+
+	switch kind {
+	case "", "library", "library-with-command":
+		if base := path.Base(name); !token.IsIdentifier(base) {
+			return fmt.Errorf("invalid module directory: %q; %q is not a valid package name", name, base)
+		}
+	}
+	return nil
+}
+
+// module returns the project for the module "name" of the workspace.
+func (p *project) module(name string) *project {
+	cfg := *p.cfg
+	cfg.Program = path.Base(name)
+	cfg.ImportPath = path.Join(p.cfg.ImportPath, name)
+	cfg.Modules = nil
+
+	return &project{p.dataDir, p.tmpl, &cfg}
+}
+
+// renderModule renders the files of the module "name" of the workspace.
+// The names of files are relative to the workspace.
+func (p *project) renderModule(name string) ([]renderedFile, error) {
+	mp := p.module(name)
+
+	layout, err := mp.layout()
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %s", name, err)
+	}
+	files := make([]renderedFile, 0, len(layout))
+
+	for _, v := range layout {
+		data, err := mp.renderSource(v.name, v.tmplName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path.Join(name, v.name), err)
+		}
+		files = append(files, renderedFile{path.Join(name, v.name), data})
+	}
+	return files, nil
+}
+
+// AddModule adds the modules "names" to the workspace created in "dir",
+// registering them in its file "go.work", in the readme file and in the file
+// of continuous integration.
+// Returns the changes done, sorted by file name.
+//
+// Unlike the commands which detect the license, it is limited to workspaces
+// created by Gowizard, since the files are merged with the ones recorded in
+// the manifest.
+func AddModule(dir string, names ...string) ([]UpgradeChange, error) {
+	if _, err := os.Stat(filepath.Join(dir, _MANIFEST)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: modules can only be added to workspaces created by Gowizard; manifest %s not found",
+			dir, _MANIFEST)
+	}
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Conf.Modules) == 0 {
+		return nil, fmt.Errorf("%s: not a workspace", dir)
+	}
+
+	added := make(map[string]bool)
+	for _, name := range names {
+		if err = checkModule(name, m.Conf.Kind); err != nil {
+			return nil, err
+		}
+		for _, v := range m.Conf.Modules {
+			if v == name {
+				return nil, fmt.Errorf("module %q already in the workspace", name)
+			}
+		}
+		if _, err = os.Stat(filepath.Join(dir, name)); err == nil {
+			return nil, fmt.Errorf("directory %q already exists", name)
+		}
+
+		m.Conf.Modules = append(m.Conf.Modules, name)
+		added[name] = true
+	}
+
+	for name := range added {
+		if err = os.MkdirAll(filepath.Join(dir, name, "testdata"), _DIR_PERM); err != nil {
+			return nil, fmt.Errorf("directory error: %s", err)
+		}
+	}
+
+	ci, _ := m.Conf.ciFile()
+
+	// Only "go.work", the readme, the file of CI and the files of new modules
+	// are touched.
+	return m.upgrade(dir, func(file string) bool {
+		if file == "go.work" || file == m.Conf.readmeFile() || file == ci {
+			return true
+		}
+		for name := range added {
+			if strings.HasPrefix(file, name+"/") {
+				return true
+			}
+		}
+		return false
+	})
+}
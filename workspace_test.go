@@ -0,0 +1,143 @@
+// Copyright 2010 Jonas mg
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckModule(t *testing.T) {
+	tests := []struct {
+		name, kind string
+		ok         bool
+	}{
+		{"api", "", true},
+		{"lib/client", "library", true},
+		{"my-tool", "command", true},
+		{"my-mod", "", false},
+		{"lib/my-mod", "library-with-command", false},
+		{"1api", "library", false},
+		{"type", "library", false},
+		{"", "", false},
+		{".", "", false},
+		{"..", "", false},
+		{"../api", "", false},
+		{"/api", "", false},
+		{"api/", "", false},
+		{"lib//api", "", false},
+	}
+
+	for _, tt := range tests {
+		if err := checkModule(tt.name, tt.kind); (err == nil) != tt.ok {
+			t.Errorf("%q (%s): got error %v", tt.name, tt.kind, err)
+		}
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	setDataDir(t)
+
+	p := newTestProject(t, &Conf{
+		Project: "Hello", ImportPaths: []string{"example.com"}, Modules: []string{"api", "lib/client"},
+	})
+	if err := p.Create(); err != nil {
+		t.Fatal(err)
+	}
+	dir := p.cfg.Program
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := []struct {
+		name, content string
+	}{
+		{"go.work", "use (\n\t./api\n\t./lib/client\n)\n"},
+		{"api/go.mod", "module example.com/hello/api\n"},
+		{"lib/client/go.mod", "module example.com/hello/lib/client\n"},
+		{"api/api.go", "package api\n"},
+		{"lib/client/client.go", "package client\n"},
+		{"README.md", "\tgo get example.com/hello/lib/client\n"},
+	}
+	for _, tt := range tests {
+		if got := read(tt.name); !strings.Contains(got, tt.content) {
+			t.Errorf("%s: without %q:\n%s", tt.name, tt.content, got)
+		}
+	}
+	for _, name := range []string{"go.mod", "hello.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: created at the root of the workspace", name)
+		}
+	}
+
+	// The changes of the user are kept.
+	writeTestFile(t, filepath.Join(dir, "go.work"), read("go.work")+"\nreplace example.com/dep => ../dep\n")
+
+	changes, err := AddModule(dir, "worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]UpgradeAction)
+	for _, v := range changes {
+		actions[v.File] = v.Action
+	}
+	for name, want := range map[string]UpgradeAction{
+		"go.work":          UpgradeMerged,
+		"README.md":        UpgradeUpdated,
+		"worker/go.mod":    UpgradeAdded,
+		"worker/worker.go": UpgradeAdded,
+	} {
+		if actions[name] != want {
+			t.Errorf("%s: got action %q, want %q", name, actions[name], want)
+		}
+	}
+	if _, ok := actions["api/api.go"]; ok {
+		t.Error("module api touched")
+	}
+
+	work := read("go.work")
+	for _, v := range []string{"\t./worker\n", "replace example.com/dep => ../dep\n"} {
+		if !strings.Contains(work, v) {
+			t.Errorf("go.work: without %q:\n%s", v, work)
+		}
+	}
+	if got := read("README.md"); !strings.Contains(got, "\tgo get example.com/hello/worker\n") {
+		t.Errorf("README.md: without the module worker:\n%s", got)
+	}
+
+	m, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.Conf.Modules, " "); got != "api lib/client worker" {
+		t.Errorf("manifest: got modules %q", got)
+	}
+
+	// A module in the workspace, an invalid package name, a module added twice,
+	// and an existing directory.
+	for _, names := range [][]string{{"api"}, {"my-mod"}, {"tools", "tools"}, {"lib"}} {
+		if _, err = AddModule(dir, names...); err == nil {
+			t.Errorf("%q: no error", names)
+		}
+	}
+
+	single := newTestProject(t, &Conf{Project: "Single"})
+	if err = single.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AddModule(single.cfg.Program, "api"); err == nil || !strings.Contains(err.Error(), "not a workspace") {
+		t.Errorf("project without workspace: got error %v", err)
+	}
+}